@@ -0,0 +1,206 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 异步输出队列满时的处理策略
+const (
+	OverflowBlock      = 0 //阻塞等待(默认)
+	OverflowDropNewest = 1 //丢弃新日志
+	OverflowDropOldest = 2 //丢弃队列中最早的日志
+	OverflowDropBelow  = 3 //丢弃低于AsyncDropLevel级别的新日志, 其他级别阻塞等待
+)
+
+const DefaultAsyncQueueSize = 8192
+
+// 异步输出队列元素(flush不为nil时为Flush标记)
+type asyncItem struct {
+	record *Record
+	flush  chan struct{}
+}
+
+// 异步日志输出: 有界队列 + 单个写入协程
+type asyncWriter struct {
+	locker    sync.RWMutex //停止时与加入队列互斥
+	stopped   bool         //写入协程已停止(之后加入的日志直接写出)
+	dropped   *uint64      //丢弃的日志数量(原子操作, 保存在日志对象中, 关闭后仍可读取)
+	queue     chan asyncItem
+	overflow  int
+	dropLevel int
+	write     func(r *Record)
+	quit      chan struct{}
+	done      chan struct{}
+}
+
+func newAsyncWriter(opt *Option, dropped *uint64, write func(r *Record)) *asyncWriter {
+	size := opt.AsyncQueueSize
+	if size <= 0 {
+		size = DefaultAsyncQueueSize
+	}
+	a := &asyncWriter{
+		dropped:   dropped,
+		queue:     make(chan asyncItem, size),
+		overflow:  opt.AsyncOverflow,
+		dropLevel: opt.AsyncDropLevel,
+		write:     write,
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for {
+		select {
+		case item := <-a.queue:
+			a.handle(item)
+		case <-a.quit:
+			a.drain()
+			return
+		}
+	}
+}
+
+func (a *asyncWriter) handle(item asyncItem) {
+	if item.flush != nil {
+		close(item.flush)
+		return
+	}
+	a.write(item.record)
+}
+
+// 写出队列中剩余的日志
+func (a *asyncWriter) drain() {
+	for {
+		select {
+		case item := <-a.queue:
+			a.handle(item)
+		default:
+			return
+		}
+	}
+}
+
+// 日志加入队列(按队列满时的处理策略), 写入协程已停止时直接写出
+func (a *asyncWriter) enqueue(r *Record) {
+	a.locker.RLock()
+	defer a.locker.RUnlock()
+	if a.stopped {
+		a.write(r)
+		return
+	}
+	item := asyncItem{record: r}
+	select {
+	case a.queue <- item:
+		return
+	default:
+	}
+	switch a.overflow {
+	case OverflowDropNewest:
+		atomic.AddUint64(a.dropped, 1)
+	case OverflowDropOldest:
+		for {
+			select {
+			case a.queue <- item:
+				return
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				if old.flush != nil {
+					close(old.flush) //之前的日志已全部写出或丢弃
+				} else {
+					atomic.AddUint64(a.dropped, 1)
+				}
+			default:
+			}
+		}
+	case OverflowDropBelow:
+		if r.Level < a.dropLevel {
+			atomic.AddUint64(a.dropped, 1)
+			return
+		}
+		a.send(item)
+	default:
+		a.send(item)
+	}
+}
+
+// 阻塞加入队列(写入协程已退出时直接写出)
+func (a *asyncWriter) send(item asyncItem) {
+	select {
+	case a.queue <- item:
+	case <-a.done:
+		a.handle(item)
+	}
+}
+
+// 等待Flush之前加入队列的日志全部写出
+func (a *asyncWriter) flush() {
+	a.locker.RLock()
+	if a.stopped {
+		a.locker.RUnlock()
+		return
+	}
+	ch := make(chan struct{})
+	a.send(asyncItem{flush: ch})
+	a.locker.RUnlock()
+	select {
+	case <-ch:
+	case <-a.done:
+	}
+}
+
+// 写出队列中剩余的日志并停止写入协程(等待正在加入队列的日志)
+func (a *asyncWriter) stop() {
+	a.locker.Lock()
+	a.stopped = true
+	a.locker.Unlock()
+	close(a.quit)
+	<-a.done
+	a.drain()
+}
+
+// 等待异步队列中的日志全部写出(默认日志对象)
+func Flush() {
+	defaultLogger.Flush()
+}
+
+// 异步输出丢弃的日志数量(默认日志对象)
+func Dropped() uint64 {
+	return defaultLogger.Dropped()
+}
+
+// 等待异步队列中的日志全部写出(未开启异步输出时直接返回)
+func (l *Logger) Flush() {
+	if a := l.loadAsync(); a != nil {
+		a.flush()
+	}
+}
+
+// 异步输出丢弃的日志数量(关闭日志或关闭异步输出后仍保留)
+func (l *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+func (l *Logger) loadAsync() *asyncWriter {
+	a, _ := l.async.Load().(*asyncWriter)
+	return a
+}
+
+// 按选项开启或关闭异步输出(关闭时写出队列中剩余的日志)
+func (l *Logger) applyAsync() {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	a := l.loadAsync()
+	if l.option.Async && a == nil {
+		l.async.Store(newAsyncWriter(&l.option, &l.dropped, l.write))
+	} else if !l.option.Async && a != nil {
+		l.async.Store((*asyncWriter)(nil))
+		a.stop()
+	}
+}
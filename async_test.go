@@ -0,0 +1,144 @@
+package log
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// 可阻塞的日志输出(记录写出的日志内容)
+type blockSink struct {
+	locker  sync.Mutex
+	msgs    []string
+	entered chan struct{} //开始写出第一条日志
+	release chan struct{} //关闭后继续写出
+	once    sync.Once
+}
+
+func newBlockSink() *blockSink {
+	return &blockSink{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (s *blockSink) Write(r *Record) error {
+	s.once.Do(func() {
+		close(s.entered)
+	})
+	<-s.release
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	s.msgs = append(s.msgs, r.Msg)
+	return nil
+}
+
+func (s *blockSink) messages() []string {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+// 创建异步输出的日志对象: 写入协程阻塞在第一条日志, 队列中再放入一条日志
+func newBlockedLogger(t *testing.T, overflow, dropLevel int) (*Logger, *blockSink) {
+	t.Helper()
+	l, err := New("", Option{
+		LogLevel:       LEVEL_TRACE,
+		CloseConsole:   true,
+		Async:          true,
+		AsyncQueueSize: 1,
+		AsyncOverflow:  overflow,
+		AsyncDropLevel: dropLevel,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newBlockSink()
+	l.AddSink("block", s, LEVEL_TRACE)
+	l.Info("first")
+	select {
+	case <-s.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer not started")
+	}
+	l.Info("queued")
+	return l, s
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	cases := []struct {
+		name     string
+		overflow int
+		want     []string
+		dropped  uint64
+	}{
+		{"drop newest", OverflowDropNewest, []string{"first", "queued"}, 3},
+		{"drop oldest", OverflowDropOldest, []string{"first", "warn"}, 3},
+		{"drop below", OverflowDropBelow, []string{"first", "queued", "warn"}, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, s := newBlockedLogger(t, c.overflow, LEVEL_WARN)
+			l.Debug("debug")
+			l.Info("info")
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				l.Warn("warn") //OverflowDropBelow阻塞等待
+			}()
+			if c.overflow != OverflowDropBelow {
+				<-done
+			}
+			close(s.release)
+			<-done
+			l.Close()
+			if got := s.messages(); len(got) != len(c.want) || got[len(got)-1] != c.want[len(c.want)-1] {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+			if n := l.Dropped(); n != c.dropped {
+				t.Fatalf("dropped %d after close, want %d", n, c.dropped)
+			}
+		})
+	}
+}
+
+func TestAsyncEnqueueAfterStop(t *testing.T) {
+	var locker sync.Mutex
+	var msgs []string
+	var dropped uint64
+	a := newAsyncWriter(&Option{}, &dropped, func(r *Record) {
+		locker.Lock()
+		defer locker.Unlock()
+		msgs = append(msgs, r.Msg)
+	})
+	a.enqueue(&Record{Msg: "before"})
+	a.stop()
+	a.enqueue(&Record{Msg: "after"})
+	a.flush()
+	locker.Lock()
+	defer locker.Unlock()
+	if len(msgs) != 2 || msgs[1] != "after" {
+		t.Fatalf("record enqueued after stop lost: %v", msgs)
+	}
+}
+
+func TestAsyncFlush(t *testing.T) {
+	l, s := newBlockedLogger(t, OverflowBlock, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("last")
+		l.Flush()
+	}()
+	select {
+	case <-done:
+		t.Fatal("flush returned before records were written")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(s.release)
+	<-done
+	if got := s.messages(); len(got) != 3 || got[2] != "last" {
+		t.Fatalf("got %v", got)
+	}
+	l.Close()
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"testing"
+)
+
+// 创建输出到ioutil.Discard的日志对象(go test -bench . -benchmem)
+func newBenchLogger(opt Option) *Logger {
+	opt.CloseConsole = true
+	l, _ := New("", opt)
+	l.AddSink("discard", NewWriterSink(ioutil.Discard, NewEncoder(FormatText, opt)), LEVEL_TRACE)
+	return l
+}
+
+// 执行b.N次f并输出每次调用的平均内存分配次数(mallocs/op, 不取整: -benchmem的allocs/op会舍去小数部分)
+func benchLoop(b *testing.B, f func(i int)) {
+	var m0, m1 runtime.MemStats
+	b.ReportAllocs()
+	runtime.ReadMemStats(&m0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f(i)
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&m1)
+	b.ReportMetric(float64(m1.Mallocs-m0.Mallocs)/float64(b.N), "mallocs/op")
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	l := newBenchLogger(Option{LogLevel: LEVEL_INFO})
+	ctx := context.Background()
+	b.Run("Debug", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			l.Debug("this is a debug message", i)
+		})
+	})
+	b.Run("Debugf", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			l.Debugf("this is a debug message %d", i)
+		})
+	})
+	b.Run("DebugfConst", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			l.Debugf("this is a debug message %s", "const")
+		})
+	})
+	b.Run("Debugw", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			l.Debugw("this is a debug message", i)
+		})
+	})
+	b.Run("DebugCtx", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			l.DebugCtx(ctx, "this is a debug message", i)
+		})
+	})
+	b.Run("IsEnabled", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			if l.IsEnabled(LEVEL_DEBUG) {
+				l.Debugf("this is a debug message %d", i)
+			}
+		})
+	})
+}
+
+func BenchmarkInfo(b *testing.B) {
+	b.Run("Infof", func(b *testing.B) {
+		l := newBenchLogger(Option{LogLevel: LEVEL_INFO, ShowCaller: true})
+		benchLoop(b, func(i int) {
+			l.Infof("this is an info message %d", i)
+		})
+	})
+	b.Run("Infow", func(b *testing.B) {
+		l := newBenchLogger(Option{LogLevel: LEVEL_INFO, ShowCaller: true})
+		benchLoop(b, func(i int) {
+			l.Infow("this is an info message", i)
+		})
+	})
+	b.Run("InfofWithRoutine", func(b *testing.B) {
+		l := newBenchLogger(Option{LogLevel: LEVEL_INFO, ShowCaller: true, ShowRoutine: true})
+		benchLoop(b, func(i int) {
+			l.Infof("this is an info message %d", i)
+		})
+	})
+	b.Run("InfofJSON", func(b *testing.B) {
+		l, _ := New("", Option{LogLevel: LEVEL_INFO, CloseConsole: true, ShowCaller: true})
+		l.AddSink("discard", NewWriterSink(ioutil.Discard, NewEncoder(FormatJSON, Option{})), LEVEL_TRACE)
+		l = l.With("request_id", "abc", "user", 42)
+		benchLoop(b, func(i int) {
+			l.Infof("this is an info message %d", i)
+		})
+	})
+	b.Run("InfofParallel", func(b *testing.B) {
+		l := newBenchLogger(Option{LogLevel: LEVEL_INFO, ShowCaller: true, ShowRoutine: true})
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				l.Infof("this is an info message")
+			}
+		})
+	})
+}
+
+func BenchmarkRecent(b *testing.B) {
+	l := newBenchLogger(Option{LogLevel: LEVEL_INFO, RecentSize: 1000})
+	b.Run("Debugf", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			l.Debugf("this is a debug message %d", i)
+		})
+	})
+	b.Run("IsEnabled", func(b *testing.B) {
+		benchLoop(b, func(i int) {
+			if l.IsEnabled(LEVEL_DEBUG) {
+				b.Fatal("debug level enabled by the flight recorder")
+			}
+		})
+	})
+}
@@ -0,0 +1,279 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 调用位置信息(按PC缓存, 同一调用点只解析一次)
+type callerInfo struct {
+	file     string //文件名(不含目录)
+	relFile  string //相对模块根目录的文件路径
+	function string //完整函数名(含包路径)
+	pkgFunc  string //带包名的函数名(如: api.(*Server).Method)
+	funcName string //函数名(不含包名)
+	line     int    //行号
+}
+
+var (
+	callerLocker sync.RWMutex
+	callerCache  = make(map[uintptr]*callerInfo) //PC -> 调用位置
+)
+
+// 通过runtime.Callers返回的PC获取调用位置(内联函数按逻辑调用层级解析)
+func lookupCaller(pc uintptr) *callerInfo {
+	callerLocker.RLock()
+	c, ok := callerCache[pc]
+	callerLocker.RUnlock()
+	if ok {
+		return c
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	c = newCallerInfo(frame.Function, frame.File, frame.Line)
+	callerLocker.Lock()
+	callerCache[pc] = c
+	callerLocker.Unlock()
+	return c
+}
+
+func newCallerInfo(function, file string, line int) *callerInfo {
+	strPkg, strName := splitFuncName(function)
+	pkgFunc := path.Base(strPkg)
+	if strName != "" {
+		pkgFunc += "." + strName
+	}
+	return &callerInfo{
+		file:     path.Base(file),
+		relFile:  moduleRelPath(strPkg, file),
+		function: function,
+		pkgFunc:  pkgFunc,
+		funcName: getFuncName(function),
+		line:     line,
+	}
+}
+
+// 按调用者信息格式(CallerShort/CallerPackage/CallerModule)获取文件名和函数名
+func (c *callerInfo) format(strFormat string) (strFile, strFunc string) {
+	switch strFormat {
+	case CallerPackage:
+		return c.file, c.pkgFunc
+	case CallerModule:
+		return c.relFile, c.pkgFunc
+	}
+	return c.file, c.funcName
+}
+
+var (
+	moduleOnce  sync.Once
+	mainModule  string   //主模块路径
+	modulePaths []string //主模块和依赖模块路径(从长到短排序)
+)
+
+// 从编译信息中读取模块路径
+func loadModules() {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	mainModule = bi.Main.Path
+	if mainModule != "" {
+		modulePaths = append(modulePaths, mainModule)
+	}
+	for _, m := range bi.Deps {
+		modulePaths = append(modulePaths, m.Path)
+	}
+	sort.Slice(modulePaths, func(i, j int) bool {
+		return len(modulePaths[i]) > len(modulePaths[j])
+	})
+}
+
+// 拆分完整函数名为包路径和包内函数名(如: gopkg.in/yaml.v3.(*decoder).unmarshal)
+// 优先匹配模块路径, 否则按runtime的规则在最后一个'/'之后的第一个'.'处拆分(符号名中包路径最后一段的'.'转义为%2e)
+func splitFuncName(function string) (strPkg, strName string) {
+	moduleOnce.Do(loadModules)
+	for _, m := range modulePaths {
+		if strings.HasPrefix(function, m+".") {
+			return m, function[len(m)+1:]
+		}
+	}
+	strPath := function
+	if nIdx := strings.IndexAny(strPath, "(["); nIdx >= 0 {
+		strPath = strPath[:nIdx] //接收者和类型参数中可能含有'/'
+	}
+	nSlash := strings.LastIndex(strPath, "/")
+	nDot := strings.Index(function[nSlash+1:], ".")
+	if nDot < 0 {
+		return strings.Replace(function, "%2e", ".", -1), ""
+	}
+	nDot += nSlash + 1
+	return strings.Replace(function[:nDot], "%2e", ".", -1), function[nDot+1:]
+}
+
+// 获取相对模块根目录的文件路径(strPkg为函数所在包路径, 标准库为包路径下的文件, 无法确定模块时为文件名)
+func moduleRelPath(strPkg, file string) string {
+	moduleOnce.Do(loadModules)
+	strBase := path.Base(file)
+	if strPkg == "main" {
+		//main包的函数名不含包路径: 编译时使用-trimpath的文件路径以模块路径开头, 否则向上查找go.mod所在目录
+		if mainModule != "" && strings.HasPrefix(file, mainModule+"/") {
+			return strings.TrimPrefix(file, mainModule+"/")
+		}
+		for dir := path.Dir(file); dir != path.Dir(dir); dir = path.Dir(dir) {
+			if _, err := os.Stat(path.Join(dir, "go.mod")); err == nil {
+				return strings.TrimPrefix(file, dir+"/")
+			}
+		}
+		return strBase
+	}
+	for _, m := range modulePaths {
+		if strPkg == m {
+			return strBase
+		}
+		if strings.HasPrefix(strPkg, m+"/") {
+			return strPkg[len(m)+1:] + "/" + strBase
+		}
+	}
+	return strPkg + "/" + strBase
+}
+
+// 获取函数名(不含包名和接收者)
+func getFuncName(function string) (name string) {
+	ns := strings.Split(function, ".")
+	name = ns[len(ns)-1]
+	return
+}
+
+var (
+	helperLocker sync.RWMutex
+	helpers      = make(map[string]struct{}) //Helper标记的函数(完整函数名)
+	helperCount  int32                       //Helper标记的函数数量(为0时不检查)
+)
+
+// 标记调用者为日志辅助函数(类似testing.T.Helper), 获取日志调用位置和调用堆栈时跳过该函数
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	function := lookupCaller(pcs[0]).function
+	if isHelper(function) {
+		return
+	}
+	helperLocker.Lock()
+	defer helperLocker.Unlock()
+	helpers[function] = struct{}{}
+	atomic.StoreInt32(&helperCount, int32(len(helpers)))
+}
+
+// 是否为Helper标记的函数
+func isHelper(function string) bool {
+	if atomic.LoadInt32(&helperCount) == 0 {
+		return false
+	}
+	helperLocker.RLock()
+	defer helperLocker.RUnlock()
+	_, ok := helpers[function]
+	return ok
+}
+
+// 获取调用者文件名、函数名和行号(skip含义同runtime.Caller, 跳过Helper标记的函数)
+func getCaller(skip int) (strFile, strFunc string, nLineNo int) {
+	c := getCallerInfo(skip + 1)
+	if c == nil {
+		return
+	}
+	return c.file, c.funcName, c.line
+}
+
+// 获取调用位置(skip含义同runtime.Caller, 跳过Helper标记的函数)
+func getCallerInfo(skip int) *callerInfo {
+	if pc := getCallerPC(skip + 1); pc != 0 {
+		return lookupCaller(pc)
+	}
+	return nil
+}
+
+// 获取调用者PC(skip含义同runtime.Caller, 跳过Helper标记的函数), 通过lookupCaller解析
+func getCallerPC(skip int) uintptr {
+	if atomic.LoadInt32(&helperCount) == 0 {
+		var pcs [1]uintptr
+		if runtime.Callers(skip+1, pcs[:]) == 0 {
+			return 0
+		}
+		return pcs[0]
+	}
+	if pcs := getCallers(skip+1, 1); len(pcs) > 0 {
+		return pcs[0]
+	}
+	return 0
+}
+
+// 获取调用堆栈(每个元素格式: file:line func(), 文件名和函数名按调用者信息格式)
+func getStack(skip, n int, strFormat string) (stack []string) {
+	return fmtCallers(getCallers(skip+1, n), strFormat)
+}
+
+// 获取最多n层调用的PC列表(skip含义同runtime.Callers, 跳过开头Helper标记的函数)
+func getCallers(skip, n int) []uintptr {
+	pcs := make([]uintptr, n+8)
+	for {
+		pcs = pcs[:runtime.Callers(skip+1, pcs[:cap(pcs)])]
+		i := 0
+		for i < len(pcs) && isHelper(lookupCaller(pcs[i]).function) {
+			i++
+		}
+		if i < len(pcs) || len(pcs) < cap(pcs) {
+			pcs = pcs[i:]
+			break
+		}
+		skip += i
+	}
+	if len(pcs) > n {
+		pcs = pcs[:n]
+	}
+	return pcs
+}
+
+// 将PC列表转换为调用堆栈
+func fmtCallers(pcs []uintptr, strFormat string) (stack []string) {
+	stack = make([]string, 0, len(pcs))
+	for _, pc := range pcs {
+		c := lookupCaller(pc)
+		if c.function == "" {
+			continue
+		}
+		strFile, strFunc := c.format(strFormat)
+		stack = append(stack, strFile+":"+strconv.Itoa(c.line)+" "+strFunc+"()")
+	}
+	return
+}
+
+// 获取当前协程ID(格式: goroutine N), 只读取调用堆栈的第一行
+func getRoutineId() (strRoutine string) {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	if nIdx := bytes.IndexByte(b, '['); nIdx > 0 {
+		return string(bytes.TrimSpace(b[:nIdx]))
+	}
+	return "<unknown routine>"
+}
+
+// 获取全部协程的调用堆栈(最大64MB)
+func getAllStacks() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64*1024*1024 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 使用指定的模块路径(从长到短排序)执行测试
+func withModules(t *testing.T, main string, paths ...string) {
+	moduleOnce.Do(loadModules)
+	oldMain, oldPaths := mainModule, modulePaths
+	mainModule, modulePaths = main, paths
+	t.Cleanup(func() {
+		mainModule, modulePaths = oldMain, oldPaths
+	})
+}
+
+func TestModuleRelPath(t *testing.T) {
+	withModules(t, "example.com/app", "example.com/app", "gopkg.in/yaml.v3")
+	cases := []struct {
+		function string
+		file     string
+		want     string
+	}{
+		{"example.com/app.Run", "/src/app/run.go", "run.go"},
+		{"example.com/app/internal/api.(*Server).Handle", "/src/app/internal/api/handler.go", "internal/api/handler.go"},
+		{"example.com/app/internal/api.Map[...].Get", "/src/app/internal/api/map.go", "internal/api/map.go"},
+		{"example.com/app/internal/api.(*Server).Handle.func1", "/src/app/internal/api/handler.go", "internal/api/handler.go"},
+		{"gopkg.in/yaml.v3.(*decoder).unmarshal", "/mod/gopkg.in/yaml.v3/decode.go", "decode.go"},
+		{"gopkg.in/yaml%2ev3.(*decoder).unmarshal", "/mod/gopkg.in/yaml.v3/decode.go", "decode.go"},
+		{"gopkg.in/yaml.v3/internal.Parse", "/mod/gopkg.in/yaml.v3/internal/parse.go", "internal/parse.go"},
+		{"example.org/x/y%2ev2.F", "/mod/example.org/x/y.v2/f.go", "example.org/x/y.v2/f.go"},
+		{"net/http.(*Server).Serve", "/go/src/net/http/server.go", "net/http/server.go"},
+		{"runtime.goexit", "/go/src/runtime/asm_amd64.s", "runtime/asm_amd64.s"},
+		{"main.main", "example.com/app/cmd/server/main.go", "cmd/server/main.go"},
+	}
+	for _, c := range cases {
+		strPkg, _ := splitFuncName(c.function)
+		if got := moduleRelPath(strPkg, c.file); got != c.want {
+			t.Errorf("moduleRelPath(%s, %s) = %s, want %s", c.function, c.file, got, c.want)
+		}
+	}
+}
+
+func TestCallerFormat(t *testing.T) {
+	withModules(t, "example.com/app", "example.com/app", "gopkg.in/yaml.v3")
+	cases := []struct {
+		function string
+		file     string
+		format   string
+		want     string
+	}{
+		{"example.com/app/internal/api.(*Server).Handle", "/src/app/internal/api/handler.go", CallerShort, "handler.go:10 Handle()"},
+		{"example.com/app/internal/api.(*Server).Handle", "/src/app/internal/api/handler.go", CallerPackage, "handler.go:10 api.(*Server).Handle()"},
+		{"example.com/app/internal/api.(*Server).Handle", "/src/app/internal/api/handler.go", CallerModule, "internal/api/handler.go:10 api.(*Server).Handle()"},
+		{"gopkg.in/yaml%2ev3.(*decoder).unmarshal", "/mod/gopkg.in/yaml.v3/decode.go", CallerPackage, "decode.go:10 yaml.v3.(*decoder).unmarshal()"},
+		{"gopkg.in/yaml%2ev3.(*decoder).unmarshal", "/mod/gopkg.in/yaml.v3/decode.go", CallerModule, "decode.go:10 yaml.v3.(*decoder).unmarshal()"},
+		{"main.main", "example.com/app/cmd/server/main.go", CallerModule, "cmd/server/main.go:10 main.main()"},
+		{"main.main", "example.com/app/cmd/server/main.go", CallerShort, "main.go:10 main()"},
+	}
+	for _, c := range cases {
+		strFile, strFunc := newCallerInfo(c.function, c.file, 10).format(c.format)
+		if got := strFile + ":10 " + strFunc + "()"; got != c.want {
+			t.Errorf("format(%s, %q) = %s, want %s", c.function, c.format, got, c.want)
+		}
+	}
+}
+
+// 调用者所在行号
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func infoHelper(l *Logger, msg string) {
+	Helper()
+	l.Info(msg)
+}
+
+func nestedHelper(l *Logger, msg string) {
+	Helper()
+	infoHelper(l, msg)
+}
+
+func errorHelper(l *Logger, msg string) error {
+	Helper()
+	return l.Errorf("%s", msg)
+}
+
+func skipWrapper(l *Logger, msg string) {
+	l.AddCallerSkip(1).Info(msg)
+}
+
+// 将默认日志对象输出到内存(测试结束后恢复)
+func captureDefault(t *testing.T) *memorySink {
+	s := &memorySink{}
+	old := defaultLogger.option.CloseConsole
+	defaultLogger.CloseConsole(true)
+	defaultLogger.AddSink("memory", s, LEVEL_TRACE)
+	t.Cleanup(func() {
+		defaultLogger.RemoveSink("memory")
+		defaultLogger.CloseConsole(old)
+	})
+	return s
+}
+
+func TestCallerSkip(t *testing.T) {
+	l, s := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO, StackDepth: 1})
+	defer l.Close()
+	ds := captureDefault(t)
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		log  func() int //输出日志并返回调用行号
+		sink *memorySink
+	}{
+		{"method", func() int {
+			line := callerLine() + 1
+			l.Infof("method")
+			return line
+		}, s},
+		{"ctx method", func() int {
+			line := callerLine() + 1
+			l.InfoCtx(ctx, "ctx method")
+			return line
+		}, s},
+		{"with", func() int {
+			line := callerLine() + 1
+			l.With("k", "v").Infow("with", "a", 1)
+			return line
+		}, s},
+		{"error", func() int {
+			line := callerLine() + 1
+			_ = l.Errorf("error")
+			return line
+		}, s},
+		{"enter", func() int {
+			line := callerLine() + 1
+			l.Enter()
+			return line
+		}, s},
+		{"helper", func() int {
+			line := callerLine() + 1
+			infoHelper(l, "helper")
+			return line
+		}, s},
+		{"nested helper", func() int {
+			line := callerLine() + 1
+			nestedHelper(l, "nested helper")
+			return line
+		}, s},
+		{"error helper", func() int {
+			line := callerLine() + 1
+			_ = errorHelper(l, "error helper")
+			return line
+		}, s},
+		{"caller skip", func() int {
+			line := callerLine() + 1
+			skipWrapper(l, "caller skip")
+			return line
+		}, s},
+		{"package", func() int {
+			line := callerLine() + 1
+			Infof("package")
+			return line
+		}, ds},
+		{"package ctx", func() int {
+			line := callerLine() + 1
+			InfoCtx(ctx, "package ctx")
+			return line
+		}, ds},
+		{"package enter", func() int {
+			line := callerLine() + 1
+			Enter()
+			return line
+		}, ds},
+		{"package helper", func() int {
+			line := callerLine() + 1
+			infoHelper(defaultLogger, "package helper")
+			return line
+		}, ds},
+	}
+	for _, c := range cases {
+		n := len(c.sink.load())
+		line := c.log()
+		records := c.sink.load()
+		if len(records) != n+1 {
+			t.Fatalf("%s: got %d records", c.name, len(records)-n)
+		}
+		r := records[n]
+		if r.File != "caller_test.go" || r.Line != line {
+			t.Errorf("%s: got %s:%d, want caller_test.go:%d", c.name, r.File, r.Line, line)
+		}
+		if len(r.Stack) > 0 && !strings.HasPrefix(r.Stack[0], "caller_test.go:"+strconv.Itoa(line)+" ") {
+			t.Errorf("%s: stack starts at %s", c.name, r.Stack[0])
+		}
+	}
+}
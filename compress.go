@@ -0,0 +1,123 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 备份文件压缩算法
+const (
+	CompressGzip = "gzip" //gzip压缩(内置)
+	CompressZstd = "zstd" //zstd压缩(需通过RegisterCompressor注册实现)
+)
+
+// 压缩中的临时文件后缀(不计入备份文件, 压缩完成后重命名)
+const compressTempExt = ".tmp"
+
+// 备份文件压缩器
+type Compressor struct {
+	Ext       string                                    //压缩文件扩展名(如: .gz)
+	NewWriter func(w io.Writer) (io.WriteCloser, error) //创建压缩写入对象
+}
+
+var (
+	compressLocker sync.RWMutex
+	compressors    = map[string]*Compressor{
+		CompressGzip: {
+			Ext: ".gz",
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			},
+		},
+	}
+)
+
+// 注册备份文件压缩算法, 如zstd(github.com/klauspost/compress/zstd):
+//
+//	log.RegisterCompressor(log.CompressZstd, ".zst", func(w io.Writer) (io.WriteCloser, error) {
+//		return zstd.NewWriter(w)
+//	})
+func RegisterCompressor(name, ext string, newWriter func(w io.Writer) (io.WriteCloser, error)) {
+	compressLocker.Lock()
+	defer compressLocker.Unlock()
+	compressors[name] = &Compressor{Ext: ext, NewWriter: newWriter}
+}
+
+func getCompressor(name string) *Compressor {
+	compressLocker.RLock()
+	defer compressLocker.RUnlock()
+	return compressors[name]
+}
+
+// 检查压缩算法是否已注册(空表示不压缩)
+func checkCompressor(name string) error {
+	if name != "" && getCompressor(name) == nil {
+		return fmt.Errorf("compressor %s is not registered", name)
+	}
+	return nil
+}
+
+// 去掉已注册的压缩文件扩展名(同一备份的压缩前后文件视为同一个备份)
+func trimCompressExt(name string) string {
+	compressLocker.RLock()
+	defer compressLocker.RUnlock()
+	for _, c := range compressors {
+		if strings.HasSuffix(name, c.Ext) {
+			return strings.TrimSuffix(name, c.Ext)
+		}
+	}
+	return name
+}
+
+// 压缩备份文件: 先写入临时文件, 完成后重命名为正式压缩文件并删除原文件
+func compressFile(strPath string, c *Compressor) (err error) {
+	src, err := os.Open(strPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	strDest := strPath + c.Ext
+	strTemp := strDest + compressTempExt
+	dst, err := os.OpenFile(strTemp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(strTemp)
+		}
+	}()
+	w, err := c.NewWriter(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	//保留原文件修改时间(备份清理按修改时间排序)
+	_ = os.Chtimes(strTemp, fi.ModTime(), fi.ModTime())
+	if err = os.Rename(strTemp, strDest); err != nil {
+		return fmt.Errorf("rename %s to %s failed %s", strTemp, strDest, err)
+	}
+	_ = src.Close()
+	return os.Remove(strPath)
+}
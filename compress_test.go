@@ -0,0 +1,130 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressPending(t *testing.T) {
+	dir := t.TempDir()
+	strPath := filepath.Join(dir, "app.log")
+	leftovers := []string{"app.log.20261017010203", "app.log.2026-10-16", "app.log.2026-10-15.1"}
+	for _, name := range leftovers {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//上次运行遗留的压缩临时文件和无关文件
+	for _, name := range []string{"app.log.2026-10-14.gz.tmp", "app.log.bak"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opt := Option{filePath: strPath, Compress: CompressGzip}
+	m := newFileSink(&opt)
+	if err := m.open(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"app.log", "app.log.2026-10-15.1.gz", "app.log.2026-10-16.gz", "app.log.20261017010203.gz", "app.log.bak"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, name := range leftovers {
+		f, err := os.Open(filepath.Join(dir, name+".gz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		_ = f.Close()
+		if err != nil || string(data) != name {
+			t.Fatalf("%s: unexpected content %q %v", name, data, err)
+		}
+	}
+}
+
+func TestCompressOnRotate(t *testing.T) {
+	m := newTestFileSink(t, Option{RotateEvery: RotateHourly, Compress: CompressGzip})
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := m.Write(&Record{Time: now.Add(time.Duration(i) * time.Hour), Level: LEVEL_INFO, Msg: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.close(); err != nil {
+		t.Fatal(err)
+	}
+	names := listDir(t, filepath.Dir(m.option.filePath))
+	if len(names) != 5 {
+		t.Fatalf("expect log file and 4 compressed backups, got %v", names)
+	}
+	for _, name := range names[1:] {
+		if !strings.HasSuffix(name, ".gz") {
+			t.Errorf("backup not compressed: %s", name)
+		}
+	}
+}
+
+func TestCleanSkipsCompressing(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	names := []string{"app.log.2026-10-17", "app.log.2026-10-16", "app.log.2026-10-15"}
+	for i, name := range names {
+		strPath := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(strPath, []byte(name), 0666); err != nil {
+			t.Fatal(err)
+		}
+		ts := now.Add(-time.Duration(i) * time.Hour)
+		_ = os.Chtimes(strPath, ts, ts)
+	}
+	opt := Option{filePath: filepath.Join(dir, "app.log"), MaxBackups: 1}
+	m := newFileSink(&opt)
+	m.compress.busy = map[string]bool{filepath.Join(dir, names[2]): true}
+	_ = m.cleanBackupLog()
+	want := []string{names[2], names[0]}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestOpenUnregisteredCompressor(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New("", Option{CloseConsole: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	opt := Option{CloseConsole: true, Compress: "unknown"}
+	if err := l.Open(filepath.Join(dir, "app.log"), opt); err == nil {
+		t.Fatal("Open accepted an unregistered compressor")
+	}
+	if err := l.OpenLevelFile(LEVEL_ERROR, filepath.Join(dir, "error.log"), opt); err == nil {
+		t.Fatal("OpenLevelFile accepted an unregistered compressor")
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Fatalf("log files created: %v", names)
+	}
+	RegisterCompressor("unknown", ".unknown", func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	})
+	defer func() {
+		compressLocker.Lock()
+		delete(compressors, "unknown")
+		compressLocker.Unlock()
+	}()
+	if err := l.Open(filepath.Join(dir, "app.log"), opt); err != nil {
+		t.Fatal(err)
+	}
+}
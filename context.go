@@ -0,0 +1,150 @@
+package log
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+// 创建附带日志字段的context(如: trace_id, request_id, tenant_id), 参数格式: key1, value1, key2, value2...
+// 通过该context输出的每行日志都会附带这些字段
+func NewContext(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fields := contextFields(ctx)
+	merged := make([]Field, 0, len(fields)+len(keysAndValues)/2)
+	merged = append(merged, fields...)
+	merged = append(merged, makeFields(keysAndValues...)...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// 获取附带context字段的日志对象(默认日志对象)
+func FromContext(ctx context.Context) *Logger {
+	return defaultLogger.WithContext(ctx)
+}
+
+// 获取context中的日志字段
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextKey{}).([]Field)
+	return fields
+}
+
+// 创建附带context字段的子日志对象(context无字段和范围日志时返回自身)
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields := contextFields(ctx)
+	scope := l.scope
+	if scope == nil {
+		scope = scopeFromContext(ctx)
+	}
+	if len(fields) == 0 && scope == l.scope {
+		return l
+	}
+	merged := make([]Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	return &Logger{
+		logInfo: l.logInfo,
+		fields:  append(merged, fields...),
+		skip:    l.skip,
+		scope:   scope,
+	}
+}
+
+// 输出调试级别信息(附带context字段)
+func TraceCtx(ctx context.Context, args ...interface{}) {
+	if !defaultLogger.handledCtx(ctx, LEVEL_TRACE) {
+		return
+	}
+	defaultLogger.WithContext(ctx).output(LEVEL_TRACE, fmtArgs, args...)
+}
+
+// 输出调试级别信息(附带context字段)
+func DebugCtx(ctx context.Context, args ...interface{}) {
+	if !defaultLogger.handledCtx(ctx, LEVEL_DEBUG) {
+		return
+	}
+	defaultLogger.WithContext(ctx).output(LEVEL_DEBUG, fmtArgs, args...)
+}
+
+// 输出运行级别信息(附带context字段)
+func InfoCtx(ctx context.Context, args ...interface{}) {
+	if !defaultLogger.handledCtx(ctx, LEVEL_INFO) {
+		return
+	}
+	defaultLogger.WithContext(ctx).output(LEVEL_INFO, fmtArgs, args...)
+}
+
+// 输出警告级别信息(附带context字段)
+func WarnCtx(ctx context.Context, args ...interface{}) {
+	if !defaultLogger.handledCtx(ctx, LEVEL_WARN) {
+		return
+	}
+	defaultLogger.WithContext(ctx).output(LEVEL_WARN, fmtArgs, args...)
+}
+
+// 输出错误级别信息(附带context字段)
+func ErrorCtx(ctx context.Context, args ...interface{}) error {
+	lc := defaultLogger.WithContext(ctx)
+	err := lc.newError(LEVEL_ERROR, argsToError(args...))
+	stic.error(lc.output(LEVEL_ERROR, loggedError{err}))
+	return err
+}
+
+// 输出危险级别信息(附带context字段)
+func FatalCtx(ctx context.Context, args ...interface{}) error {
+	lc := defaultLogger.WithContext(ctx)
+	err := lc.newError(LEVEL_FATAL, argsToError(args...))
+	stic.error(lc.output(LEVEL_FATAL, loggedError{err}))
+	return err
+}
+
+// 输出调试级别信息(附带context字段)
+func (l *Logger) TraceCtx(ctx context.Context, args ...interface{}) {
+	if !l.handledCtx(ctx, LEVEL_TRACE) {
+		return
+	}
+	l.WithContext(ctx).output(LEVEL_TRACE, fmtArgs, args...)
+}
+
+// 输出调试级别信息(附带context字段)
+func (l *Logger) DebugCtx(ctx context.Context, args ...interface{}) {
+	if !l.handledCtx(ctx, LEVEL_DEBUG) {
+		return
+	}
+	l.WithContext(ctx).output(LEVEL_DEBUG, fmtArgs, args...)
+}
+
+// 输出运行级别信息(附带context字段)
+func (l *Logger) InfoCtx(ctx context.Context, args ...interface{}) {
+	if !l.handledCtx(ctx, LEVEL_INFO) {
+		return
+	}
+	l.WithContext(ctx).output(LEVEL_INFO, fmtArgs, args...)
+}
+
+// 输出警告级别信息(附带context字段)
+func (l *Logger) WarnCtx(ctx context.Context, args ...interface{}) {
+	if !l.handledCtx(ctx, LEVEL_WARN) {
+		return
+	}
+	l.WithContext(ctx).output(LEVEL_WARN, fmtArgs, args...)
+}
+
+// 输出错误级别信息(附带context字段)
+func (l *Logger) ErrorCtx(ctx context.Context, args ...interface{}) error {
+	lc := l.WithContext(ctx)
+	err := lc.newError(LEVEL_ERROR, argsToError(args...))
+	stic.error(lc.output(LEVEL_ERROR, loggedError{err}))
+	return err
+}
+
+// 输出危险级别信息(附带context字段)
+func (l *Logger) FatalCtx(ctx context.Context, args ...interface{}) error {
+	lc := l.WithContext(ctx)
+	err := lc.newError(LEVEL_FATAL, argsToError(args...))
+	stic.error(lc.output(LEVEL_FATAL, loggedError{err}))
+	return err
+}
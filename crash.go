@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 捕获panic并输出崩溃报告(必须直接defer调用: defer log.Recover())
+// 崩溃报告包含panic值、全部协程的调用堆栈和统计信息(Report), 输出到日志的全部输出并追加到崩溃文件(日志文件路径.crash)
+func Recover() {
+	if r := recover(); r != nil {
+		defaultLogger.crash(r)
+	}
+}
+
+// 启动协程执行f, 协程中的panic被捕获并输出崩溃报告
+func Go(f func()) {
+	defaultLogger.Go(f)
+}
+
+// 捕获panic并输出崩溃报告(必须直接defer调用: defer l.Recover())
+func (l *Logger) Recover() {
+	if r := recover(); r != nil {
+		l.crash(r)
+	}
+}
+
+// 启动协程执行f, 协程中的panic被捕获并输出崩溃报告
+func (l *Logger) Go(f func()) {
+	go func() {
+		defer l.Recover()
+		f()
+	}()
+}
+
+// 输出崩溃报告(由Recover调用)
+func (l *Logger) crash(value interface{}) {
+	now := time.Now()
+	msg := fmt.Sprintf("panic: %v", value)
+	goroutines := getAllStacks()
+	stats := Report()
+	depth := l.stackDepth(LEVEL_PANIC)
+	if depth == 0 {
+		depth = DefaultStackDepth
+	}
+	pcs := panicCallers(depth)
+	r := &Record{
+		Time:       now,
+		Level:      LEVEL_PANIC,
+		Routine:    getRoutineId(),
+		PID:        os.Getpid(),
+		Msg:        msg,
+		Fields:     l.fields,
+		Stack:      fmtCallers(pcs, l.option.CallerFormat),
+		Goroutines: goroutines,
+	}
+	if len(pcs) > 0 {
+		c := lookupCaller(pcs[0])
+		r.File, r.Func = c.format(l.option.CallerFormat)
+		r.Line = c.line
+	}
+	//有统计数据时附带到日志字段(压缩为单行JSON)
+	var summ summary
+	var buf bytes.Buffer
+	if json.Unmarshal([]byte(stats), &summ) == nil && len(summ.Results) > 0 && json.Compact(&buf, []byte(stats)) == nil {
+		r.Fields = append(append([]Field{}, l.fields...), Field{Key: "stats", Value: buf.String()})
+	}
+	l.DumpRecent()
+	l.emit(r)
+	l.Flush()
+	l.syncSinks()
+	_ = l.writeCrashFile(now, msg, goroutines, stats)
+}
+
+// 获取panic位置的调用PC列表(跳过Recover和runtime内部的panic处理函数)
+func panicCallers(n int) []uintptr {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	for i, pc := range pcs {
+		if lookupCaller(pc).function != "runtime.gopanic" {
+			continue
+		}
+		pcs = pcs[i+1:]
+		for len(pcs) > 1 && strings.HasPrefix(lookupCaller(pcs[0]).function, "runtime.") {
+			pcs = pcs[1:]
+		}
+		break
+	}
+	if len(pcs) > n {
+		pcs = pcs[:n]
+	}
+	return pcs
+}
+
+// 追加崩溃报告到崩溃文件(未打开日志文件时不写入)
+func (l *Logger) writeCrashFile(now time.Time, msg, goroutines, stats string) error {
+	strPath := l.option.filePath
+	if strPath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(strPath+".crash", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "==================== CRASH REPORT %s ====================\n", now.Format("2006-01-02 15:04:05.000000"))
+	fmt.Fprintf(&buf, "pid: %d\n%s\n\n", os.Getpid(), msg)
+	fmt.Fprintf(&buf, "goroutines:\n%s\n\n", strings.TrimRight(goroutines, "\n"))
+	fmt.Fprintf(&buf, "statistics:\n%s\n\n", stats)
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoCrash(t *testing.T) {
+	var index []int
+	var nilMap *map[string]int
+	cases := []struct {
+		name  string
+		f     func(line *int)
+		value string
+	}{
+		{"panic", func(line *int) {
+			*line = callerLine() + 1
+			panic("boom")
+		}, "panic: boom"},
+		{"index", func(line *int) {
+			*line = callerLine() + 1
+			index[1] = 1
+		}, "panic: runtime error: index out of range"},
+		{"nil pointer", func(line *int) {
+			*line = callerLine() + 1
+			(*nilMap)["a"] = 1
+		}, "panic: runtime error: invalid memory address"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			strPath := filepath.Join(t.TempDir(), "app.log")
+			l, err := New(strPath, Option{LogLevel: LEVEL_INFO, CloseConsole: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			s := &memorySink{}
+			l.AddSink("memory", s, LEVEL_TRACE)
+			var line int
+			l.Go(func() {
+				c.f(&line)
+			})
+			var data []byte
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				if data, _ = ioutil.ReadFile(strPath + ".crash"); len(data) > 0 {
+					break
+				}
+			}
+			report := string(data)
+			if !strings.Contains(report, "CRASH REPORT") || !strings.Contains(report, c.value) || !strings.Contains(report, "goroutine ") {
+				t.Fatalf("unexpected crash file: %q", report)
+			}
+			records := s.load()
+			if len(records) != 1 || records[0].Level != LEVEL_PANIC {
+				t.Fatalf("got %d records", len(records))
+			}
+			r := records[0]
+			if !strings.HasPrefix(r.Msg, c.value) {
+				t.Fatalf("got message %q", r.Msg)
+			}
+			if r.File != "crash_test.go" || r.Line != line {
+				t.Fatalf("got %s:%d, want crash_test.go:%d, stack %v", r.File, r.Line, line, r.Stack)
+			}
+			if !strings.Contains(r.Goroutines, "goroutine ") {
+				t.Fatalf("goroutine stacks missing: %q", r.Goroutines)
+			}
+		})
+	}
+}
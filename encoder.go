@@ -0,0 +1,274 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fatih/color"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const jsonTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// 日志记录(一行日志的全部信息)
+type Record struct {
+	Time       time.Time //日志时间
+	Level      int       //日志级别
+	File       string    //调用者文件名
+	Func       string    //调用者函数名
+	Line       int       //调用者行号
+	Routine    string    //协程ID(goroutine N)
+	PID        int       //进程ID
+	Msg        string    //日志内容
+	Fields     []Field   //结构化字段
+	Stack      []string  //调用堆栈(Option.StackLevel及以上级别, 默认ERROR)
+	Goroutines string    //全部协程的调用堆栈(FATAL级别, Option.StackAllOnFatal)
+}
+
+// 日志编码接口
+type Encoder interface {
+	Encode(r *Record) []byte
+}
+
+// 根据输出格式创建编码器(FormatText/FormatJSON/FormatLogfmt), 显示选项取自opt
+func NewEncoder(format string, opt Option) Encoder {
+	switch format {
+	case FormatJSON:
+		return &JSONEncoder{}
+	case FormatLogfmt:
+		return &LogfmtEncoder{
+			ShowProcess: opt.ShowProcess,
+			ShowRoutine: opt.ShowRoutine,
+			ShowCaller:  opt.ShowCaller,
+		}
+	default:
+		return &TextEncoder{
+			ShowProcess:    opt.ShowProcess,
+			ShowRoutine:    opt.ShowRoutine,
+			ShowCaller:     opt.ShowCaller,
+			StackMultiline: opt.StackMultiline,
+		}
+	}
+}
+
+// JSON格式保留字段(结构化字段与之重名时加fields.前缀)
+var jsonReservedKeys = map[string]bool{
+	"ts":         true,
+	"level":      true,
+	"caller":     true,
+	"func":       true,
+	"goroutine":  true,
+	"pid":        true,
+	"msg":        true,
+	"stack":      true,
+	"goroutines": true,
+}
+
+// 日志级别名称(小写且不带中括号)
+func levelString(level int) string {
+	if level < 0 || level >= len(LevelName) {
+		return strconv.Itoa(level)
+	}
+	return strings.ToLower(strings.Trim(LevelName[level], "[]"))
+}
+
+// 获取协程ID数值(获取失败时返回0)
+func routineNumber(strRoutine string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimPrefix(strRoutine, "goroutine "), 10, 64)
+	return n
+}
+
+// 文本格式编码器
+type TextEncoder struct {
+	Color          bool //终端屏幕彩色格式(含进程ID), 否则为日志文件格式(不含颜色控制字符)
+	ShowProcess    bool //显示进程ID
+	ShowRoutine    bool //显示协程ID
+	ShowCaller     bool //显示调用者信息
+	StackMultiline bool //调用堆栈每层单独一行输出
+}
+
+func (e *TextEncoder) Encode(r *Record) []byte {
+	var inf, code string
+	var colorTimeName string
+
+	strTimeFmt := fmt.Sprintf("%v", r.Time.Format("2006-01-02 15:04:05.000000"))
+	strRoutine := fmt.Sprintf("{%v}", r.Routine)
+	strPID := fmt.Sprintf("PID:%d", r.PID)
+	Name := LevelName[r.Level]
+	if !e.ShowProcess {
+		strPID = ""
+	}
+	if !e.ShowRoutine {
+		strRoutine = ""
+	}
+	inf = r.Msg
+	if len(r.Fields) > 0 {
+		inf += " " + fmtFields(r.Fields)
+	}
+	code = "<" + r.File + ":" + strconv.Itoa(r.Line) + " " + r.Func + "()" + ">"
+	if !e.ShowCaller {
+		code = ""
+	}
+	strStack := fmtStack(r.Stack, e.StackMultiline)
+	if r.Goroutines != "" {
+		strStack += "\n" + strings.TrimRight(r.Goroutines, "\n")
+	}
+	if !e.Color {
+		//日志文件格式(与标准库log.LstdFlags|log.Lmicroseconds前缀一致)
+		return []byte(r.Time.Format("2006/01/02 15:04:05.000000") + " " + Name + " " + strRoutine + " " + code + " " + inf + strStack + "\n")
+	}
+
+	switch r.Level {
+	case LEVEL_TRACE:
+		colorTimeName = fmt.Sprintf("\033[38m%v %s %s", strTimeFmt, strPID, Name)
+	case LEVEL_DEBUG:
+		colorTimeName = fmt.Sprintf("\033[34m%v %s %s", strTimeFmt, strPID, Name)
+	case LEVEL_INFO:
+		colorTimeName = fmt.Sprintf("\033[32m%v %s %s", strTimeFmt, strPID, Name)
+	case LEVEL_WARN:
+		colorTimeName = fmt.Sprintf("\033[33m%v %s %s", strTimeFmt, strPID, Name)
+	case LEVEL_ERROR:
+		colorTimeName = fmt.Sprintf("\033[31m%v %s %s", strTimeFmt, strPID, Name)
+	case LEVEL_FATAL:
+		colorTimeName = fmt.Sprintf("\033[35m%v %s %s", strTimeFmt, strPID, Name)
+	case LEVEL_PANIC:
+		colorTimeName = fmt.Sprintf("\033[35m%v %s %s", strTimeFmt, strPID, Name)
+	default:
+		colorTimeName = fmt.Sprintf("\033[34m%v %s %s", strTimeFmt, strPID, Name)
+	}
+	outstr := "\033[1m" + colorTimeName + " " + strRoutine + " " + code + "\033[0m " + inf
+	if strStack != "" {
+		outstr += color.CyanString(strStack)
+	}
+	return []byte(outstr + "\n")
+}
+
+// JSON Lines格式编码器(每行一个JSON对象)
+type JSONEncoder struct{}
+
+func (e *JSONEncoder) Encode(r *Record) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONField(&buf, "ts", r.Time.Format(jsonTimeFormat), true)
+	writeJSONField(&buf, "level", levelString(r.Level), false)
+	writeJSONField(&buf, "caller", r.File+":"+strconv.Itoa(r.Line), false)
+	writeJSONField(&buf, "func", r.Func, false)
+	writeJSONField(&buf, "goroutine", routineNumber(r.Routine), false)
+	writeJSONField(&buf, "pid", r.PID, false)
+	writeJSONField(&buf, "msg", r.Msg, false)
+	for _, f := range jsonFields(r.Fields) {
+		writeJSONField(&buf, f.Key, f.Value, false)
+	}
+	if len(r.Stack) > 0 {
+		writeJSONField(&buf, "stack", r.Stack, false)
+	}
+	if r.Goroutines != "" {
+		writeJSONField(&buf, "goroutines", r.Goroutines, false)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// 转换为JSON字段(与保留字段重名时加fields.前缀), 同名字段只保留最后一个值(位置为第一次出现的位置)
+func jsonFields(fields []Field) []Field {
+	out := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		key := f.Key
+		if jsonReservedKeys[key] {
+			key = "fields." + key
+		}
+		exist := false
+		for i := range out {
+			if out[i].Key == key {
+				out[i].Value = f.Value
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			out = append(out, Field{Key: key, Value: f.Value})
+		}
+	}
+	return out
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		buf.WriteByte(',')
+	}
+	data, _ := json.Marshal(key)
+	buf.Write(data)
+	buf.WriteByte(':')
+	buf.Write(jsonValue(value))
+}
+
+// 字段值编码为JSON(error类型输出错误信息, 无法编码的值输出为字符串)
+func jsonValue(value interface{}) []byte {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%+v", value))
+	}
+	return data
+}
+
+// logfmt格式编码器(ts=... level=info caller=main.go:67 func=main msg="...")
+type LogfmtEncoder struct {
+	ShowProcess bool //显示进程ID
+	ShowRoutine bool //显示协程ID
+	ShowCaller  bool //显示调用者信息
+}
+
+func (e *LogfmtEncoder) Encode(r *Record) []byte {
+	var buf bytes.Buffer
+	writeLogfmtField(&buf, "ts", r.Time.Format(jsonTimeFormat))
+	writeLogfmtField(&buf, "level", levelString(r.Level))
+	if e.ShowProcess {
+		writeLogfmtField(&buf, "pid", r.PID)
+	}
+	if e.ShowRoutine {
+		writeLogfmtField(&buf, "goroutine", routineNumber(r.Routine))
+	}
+	if e.ShowCaller {
+		writeLogfmtField(&buf, "caller", r.File+":"+strconv.Itoa(r.Line))
+		writeLogfmtField(&buf, "func", r.Func)
+	}
+	writeLogfmtField(&buf, "msg", r.Msg)
+	for _, f := range r.Fields {
+		writeLogfmtField(&buf, f.Key, f.Value)
+	}
+	if len(r.Stack) > 0 {
+		writeLogfmtField(&buf, "stack", strings.Join(r.Stack, "; "))
+	}
+	if r.Goroutines != "" {
+		writeLogfmtField(&buf, "goroutines", r.Goroutines)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func writeLogfmtField(buf *bytes.Buffer, key string, value interface{}) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	buf.WriteString(fmtFieldValue(value))
+}
+
+// logfmt的key不能含空格、等号、引号和控制字符(替换为下划线)
+func logfmtKey(key string) string {
+	if key == "" {
+		return badKey
+	}
+	return strings.Map(func(r rune) rune {
+		if needQuote(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONEncoderDuplicateFields(t *testing.T) {
+	r := &Record{
+		Time:  time.Now(),
+		Level: LEVEL_INFO,
+		Msg:   "hello",
+		Fields: []Field{
+			{Key: "a", Value: 1},
+			{Key: "msg", Value: "field"},
+			{Key: "b", Value: "x"},
+			{Key: "a", Value: 2},
+			{Key: "msg", Value: "last"},
+		},
+	}
+	data := (&JSONEncoder{}).Encode(r)
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("invalid json %s: %s", data, err)
+	}
+	if n := strings.Count(string(data), `"a":`); n != 1 {
+		t.Fatalf("key a written %d times: %s", n, data)
+	}
+	if m["a"] != float64(2) || m["fields.msg"] != "last" || m["msg"] != "hello" || m["b"] != "x" {
+		t.Fatalf("unexpected fields: %s", data)
+	}
+	if strings.Index(string(data), `"a":`) > strings.Index(string(data), `"b":`) {
+		t.Fatalf("field order changed: %s", data)
+	}
+}
+
+func TestFmtFieldsKey(t *testing.T) {
+	cases := []struct {
+		fields []Field
+		want   string
+	}{
+		{[]Field{{Key: "user id", Value: 1}}, "user_id=1"},
+		{[]Field{{Key: "a=b", Value: "c d"}}, `a_b="c d"`},
+		{[]Field{{Key: `"q"`, Value: ""}}, `_q_=""`},
+		{[]Field{{Key: "", Value: 1}}, badKey + "=1"},
+	}
+	for _, c := range cases {
+		if got := fmtFields(c.fields); got != c.want {
+			t.Errorf("fmtFields(%v) = %s, want %s", c.fields, got, c.want)
+		}
+	}
+}
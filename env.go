@@ -0,0 +1,110 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 从环境变量加载日志选项(覆盖opt中的默认值), 返回环境变量中的日志文件路径
+func loadEnvOption(opt *Option) (strPath string) {
+	if v, ok := lookupEnv(ENV_LOG_LEVEL); ok {
+		if nLevel, ok := levelFromString(v); ok {
+			opt.LogLevel = nLevel
+		} else if nLevel, err := strconv.Atoi(v); err == nil {
+			opt.LogLevel = nLevel
+		}
+	}
+	loadEnvInt(ENV_LOG_FILE_SIZE, &opt.FileSize)
+	loadEnvInt(ENV_LOG_MAX_BACKUPS, &opt.MaxBackups)
+	loadEnvInt(ENV_LOG_MAX_AGE, &opt.MaxAge)
+	loadEnvInt(ENV_LOG_MAX_TOTAL_SIZE, &opt.MaxTotalSize)
+	if v, ok := lookupEnv(ENV_LOG_CONSOLE); ok {
+		if b, ok := parseEnvBool(v); ok {
+			opt.CloseConsole = !b
+		}
+	}
+	loadEnvBool(ENV_LOG_SHOW_PROCESS, &opt.ShowProcess)
+	loadEnvBool(ENV_LOG_SHOW_ROUTINE, &opt.ShowRoutine)
+	loadEnvBool(ENV_LOG_SHOW_CALLER, &opt.ShowCaller)
+	loadEnvBool(ENV_LOG_ASYNC, &opt.Async)
+	loadEnvFormat(ENV_LOG_FORMAT, &opt.Format)
+	loadEnvFormat(ENV_LOG_CONSOLE_FORMAT, &opt.ConsoleFormat)
+	if v, ok := lookupEnv(ENV_LOG_CALLER_FORMAT); ok {
+		switch strings.ToLower(v) {
+		case "short":
+			opt.CallerFormat = CallerShort
+		case CallerPackage:
+			opt.CallerFormat = CallerPackage
+		case CallerModule:
+			opt.CallerFormat = CallerModule
+		}
+	}
+	if v, ok := lookupEnv(ENV_LOG_ROTATE); ok {
+		switch strings.ToLower(v) {
+		case "hourly":
+			opt.RotateEvery = RotateHourly
+		case "daily":
+			opt.RotateEvery = RotateDaily
+		case "midnight":
+			opt.RotateAtMidnight = true
+		default:
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				opt.RotateEvery = d
+			}
+		}
+	}
+	if v, ok := lookupEnv(ENV_LOG_COMPRESS); ok {
+		opt.Compress = strings.ToLower(v)
+	}
+	strPath, _ = lookupEnv(ENV_LOG_FILE)
+	return
+}
+
+// 读取非空环境变量
+func lookupEnv(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+func loadEnvInt(key string, value *int) {
+	if v, ok := lookupEnv(key); ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			*value = n
+		}
+	}
+}
+
+func loadEnvBool(key string, value *bool) {
+	if v, ok := lookupEnv(key); ok {
+		if b, ok := parseEnvBool(v); ok {
+			*value = b
+		}
+	}
+}
+
+func loadEnvFormat(key string, value *string) {
+	if v, ok := lookupEnv(key); ok {
+		switch strings.ToLower(v) {
+		case "text":
+			*value = FormatText
+		case FormatJSON:
+			*value = FormatJSON
+		case FormatLogfmt:
+			*value = FormatLogfmt
+		}
+	}
+}
+
+// 解析布尔型环境变量(支持 true/false 1/0 on/off yes/no)
+func parseEnvBool(v string) (bool, bool) {
+	switch strings.ToLower(v) {
+	case "on", "yes", "y":
+		return true, true
+	case "off", "no", "n":
+		return false, true
+	}
+	b, err := strconv.ParseBool(v)
+	return b, err == nil
+}
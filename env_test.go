@@ -0,0 +1,46 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEnvOption(t *testing.T) {
+	setenv(t, ENV_LOG_LEVEL, "warn")
+	setenv(t, ENV_LOG_SHOW_CALLER, "off")
+	setenv(t, ENV_LOG_FORMAT, "json")
+	setenv(t, ENV_LOG_FILE, "logs/env.log")
+	opt := Option{LogLevel: LEVEL_INFO, ShowCaller: true}
+	strPath := loadEnvOption(&opt)
+	if opt.LogLevel != LEVEL_WARN || opt.ShowCaller || opt.Format != FormatJSON || strPath != "logs/env.log" {
+		t.Fatalf("unexpected option %+v path %s", opt, strPath)
+	}
+}
+
+func TestOpenExplicitOption(t *testing.T) {
+	setenv(t, ENV_LOG_LEVEL, "info")
+	setenv(t, ENV_LOG_SHOW_CALLER, "true")
+	old := defaultLogger.option
+	defer defaultLogger.setOption(old)
+	strPath := filepath.Join(t.TempDir(), "test.log")
+	if err := Open(strPath, Option{LogLevel: LEVEL_TRACE, ShowCaller: false, CloseConsole: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	if opt := defaultLogger.option; opt.LogLevel != LEVEL_TRACE || opt.ShowCaller {
+		t.Fatalf("explicit option overridden by environment: %+v", opt)
+	}
+}
+
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	_ = os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 日志错误(Error/Errorf/Fatal/Fatalf等函数的返回值): 保留被包装的原始错误(支持errors.Is/errors.As),
+// 并附带日志调用位置、调用堆栈和结构化字段, 上层调用者可以检查或再次输出
+type LogError struct {
+	File   string   //日志调用者文件名
+	Func   string   //日志调用者函数名
+	Line   int      //日志调用者行号
+	Stack  []string //调用堆栈(按Option.StackLevel/StackDepth捕获)
+	Fields []Field  //结构化字段(含被包装的日志错误的字段)
+	err    error
+}
+
+func (e *LogError) Error() string {
+	return e.err.Error()
+}
+
+// 返回被包装的错误(fmt.Errorf的%w参数或日志参数中的error)
+func (e *LogError) Unwrap() error {
+	return e.err
+}
+
+// 由日志函数直接输出的日志错误(调用位置、调用堆栈和字段已在创建时获取)
+type loggedError struct {
+	*LogError
+}
+
+// 日志参数中的错误(错误信息为格式化后的日志内容)
+type causeError struct {
+	msg   string
+	cause error
+}
+
+func (e *causeError) Error() string {
+	return e.msg
+}
+
+func (e *causeError) Unwrap() error {
+	return e.cause
+}
+
+// 将日志参数转换为错误(格式化字符串中的%w和参数中的error都会被保留)
+func argsToError(args ...interface{}) error {
+	if len(args) > 0 {
+		if s, ok := args[0].(string); ok && strings.Contains(s, "%") {
+			return fmt.Errorf(s, args[1:]...)
+		}
+	}
+	msg := fmtString(args...)
+	for _, v := range args {
+		if err, ok := v.(error); ok {
+			return &causeError{msg: msg, cause: err}
+		}
+	}
+	return errors.New(msg)
+}
+
+// 创建日志错误并获取调用位置和调用堆栈(只能由日志函数直接调用)
+func (l *Logger) newError(level int, err error) *LogError {
+	e := &LogError{
+		Fields: errorFields(l.fields, err),
+		err:    err,
+	}
+	if c := getCallerInfo(3 + l.skip); c != nil {
+		e.File, e.Func = c.format(l.option.CallerFormat)
+		e.Line = c.line
+	}
+	if depth := l.stackDepth(level); depth > 0 {
+		e.Stack = getStack(3+l.skip, depth, l.option.CallerFormat)
+	}
+	return e
+}
+
+// 合并被包装的日志错误的结构化字段(已有同名字段时不覆盖)
+func errorFields(fields []Field, err error) []Field {
+	var inner *LogError
+	if !errors.As(err, &inner) || len(inner.Fields) == 0 {
+		return fields
+	}
+	merged := make([]Field, 0, len(fields)+len(inner.Fields))
+	merged = append(merged, fields...)
+	for _, f := range inner.Fields {
+		exist := false
+		for _, v := range fields {
+			if v.Key == f.Key {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
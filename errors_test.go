@@ -0,0 +1,93 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+var errSentinel = errors.New("sentinel")
+
+func TestErrorWrap(t *testing.T) {
+	l, _ := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO})
+	defer l.Close()
+	cases := []struct {
+		name string
+		err  error
+		msg  string
+	}{
+		{"errorf %w", l.Errorf("read config: %w", errSentinel), "read config: sentinel"},
+		{"error argument", l.Error("read config ", errSentinel), "read config sentinel"},
+		{"wrapped twice", l.Errorf("handler: %w", fmt.Errorf("read: %w", errSentinel)), "handler: read: sentinel"},
+		{"error only", l.Error(errSentinel), "sentinel"},
+	}
+	for _, c := range cases {
+		if !errors.Is(c.err, errSentinel) {
+			t.Errorf("%s: errors.Is failed for %v", c.name, c.err)
+		}
+		if c.err.Error() != c.msg {
+			t.Errorf("%s: got %q, want %q", c.name, c.err.Error(), c.msg)
+		}
+	}
+}
+
+func TestErrorAs(t *testing.T) {
+	l, s := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO})
+	defer l.Close()
+	_, _, line, _ := runtime.Caller(0)
+	err := fmt.Errorf("outer: %w", l.Errorf("inner"))
+	var e *LogError
+	if !errors.As(err, &e) {
+		t.Fatalf("errors.As failed for %v", err)
+	}
+	if e.File != "errors_test.go" || e.Line != line+1 || e.Func != "TestErrorAs" {
+		t.Fatalf("unexpected call site %s:%d %s", e.File, e.Line, e.Func)
+	}
+	if len(e.Stack) == 0 {
+		t.Fatal("stack not captured for ERROR")
+	}
+	records := s.load()
+	if len(records) != 1 || records[0].File != e.File || records[0].Line != e.Line {
+		t.Fatalf("record call site differs from the error: %+v", records)
+	}
+}
+
+func TestErrorFieldsRelogged(t *testing.T) {
+	l, s := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO})
+	defer l.Close()
+	inner := l.With("user_id", 1001, "step", "load").Errorf("read config: %w", errSentinel)
+	outer := l.With("step", "handle").Errorf("handler: %w", inner)
+	records := s.load()
+	if len(records) != 2 {
+		t.Fatalf("got %d records", len(records))
+	}
+	want := []Field{{Key: "step", Value: "handle"}, {Key: "user_id", Value: 1001}}
+	if got := records[1].Fields; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got fields %v, want %v", got, want)
+	}
+	var e *LogError
+	if !errors.As(outer, &e) || fmt.Sprint(e.Fields) != fmt.Sprint(want) {
+		t.Fatalf("got error fields %v, want %v", e.Fields, want)
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	inner := &LogError{err: errSentinel, Fields: []Field{{Key: "a", Value: 1}, {Key: "b", Value: 2}}}
+	cases := []struct {
+		name   string
+		fields []Field
+		err    error
+		want   []Field
+	}{
+		{"plain error", []Field{{Key: "a", Value: 0}}, errSentinel, []Field{{Key: "a", Value: 0}}},
+		{"log error", nil, inner, inner.Fields},
+		{"keep existing", []Field{{Key: "b", Value: 3}}, inner, []Field{{Key: "b", Value: 3}, {Key: "a", Value: 1}}},
+		{"wrapped", []Field{{Key: "c", Value: 4}}, fmt.Errorf("x: %w", inner), []Field{{Key: "c", Value: 4}, {Key: "a", Value: 1}, {Key: "b", Value: 2}}},
+	}
+	for _, c := range cases {
+		if got := errorFields(c.fields, c.err); fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
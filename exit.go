@@ -0,0 +1,91 @@
+package log
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	exitLocker  sync.Mutex
+	exitHooks   []func()              //退出钩子(按注册顺序执行)
+	exitRoutine string                //正在退出进程的协程ID
+	liveLoggers = map[*logInfo]bool{} //未关闭的日志对象(退出前全部写出并同步)
+)
+
+// 注册退出钩子(Option.FatalExit开启时FATAL级别日志输出后、进程退出前执行)
+func RegisterExitHook(hook func()) {
+	exitLocker.Lock()
+	defer exitLocker.Unlock()
+	exitHooks = append(exitHooks, hook)
+}
+
+// 加入未关闭的日志对象列表
+func registerLogger(inf *logInfo) {
+	exitLocker.Lock()
+	defer exitLocker.Unlock()
+	liveLoggers[inf] = true
+}
+
+// 从未关闭的日志对象列表中移除
+func unregisterLogger(inf *logInfo) {
+	exitLocker.Lock()
+	defer exitLocker.Unlock()
+	delete(liveLoggers, inf)
+}
+
+// 写出全部未关闭日志对象异步队列中的日志并同步日志输出
+func flushLoggers() {
+	exitLocker.Lock()
+	loggers := make([]*Logger, 0, len(liveLoggers))
+	for inf := range liveLoggers {
+		loggers = append(loggers, &Logger{logInfo: inf})
+	}
+	exitLocker.Unlock()
+	for _, l := range loggers {
+		l.Flush()
+		l.syncSinks()
+	}
+}
+
+// 执行退出钩子(单个钩子panic不影响后续钩子)
+func runExitHooks() {
+	exitLocker.Lock()
+	hooks := append([]func(){}, exitHooks...)
+	exitLocker.Unlock()
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			hook()
+		}()
+	}
+}
+
+// FATAL退出: 写出全部日志对象异步队列中的日志并同步日志输出, 执行退出钩子后退出进程
+// 其他协程同时FATAL时阻塞等待进程退出, 只有退出钩子中输出的FATAL日志返回
+func (l *Logger) exit() {
+	if !beginExit() {
+		return
+	}
+	flushLoggers()
+	runExitHooks()
+	os.Exit(1)
+}
+
+// 开始退出进程(返回false表示退出协程重入, 其他协程永久阻塞)
+func beginExit() bool {
+	routine := getRoutineId()
+	exitLocker.Lock()
+	if exitRoutine == "" {
+		exitRoutine = routine
+		exitLocker.Unlock()
+		return true
+	}
+	reentrant := exitRoutine == routine
+	exitLocker.Unlock()
+	if !reentrant {
+		select {}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBeginExit(t *testing.T) {
+	defer func() {
+		exitLocker.Lock()
+		exitRoutine = ""
+		exitLocker.Unlock()
+	}()
+	if !beginExit() {
+		t.Fatal("first exit not started")
+	}
+	if beginExit() {
+		t.Fatal("reentrant exit started again")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		beginExit()
+	}()
+	select {
+	case <-done:
+		t.Fatal("exit returned in another goroutine")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFatalExitFlushAll(t *testing.T) {
+	dir := os.Getenv("LOG_TEST_FATAL_DIR")
+	if dir != "" {
+		other, err := New(filepath.Join(dir, "other.log"), Option{LogLevel: LEVEL_INFO, CloseConsole: true, Async: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := New(filepath.Join(dir, "app.log"), Option{LogLevel: LEVEL_INFO, CloseConsole: true, Async: true, FatalExit: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		other.AddSink("slow", SinkFunc(func(r *Record) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}), LEVEL_TRACE)
+		RegisterExitHook(func() {
+			l.Fatal("hook") //退出钩子中的FATAL日志返回
+			other.Info("hook done")
+		})
+		other.Info("first")
+		other.Info("other") //写入协程阻塞在慢速输出, 退出前需要写出
+		l.Fatal("fatal")
+		t.Fatal("Fatal returned")
+	}
+	dir = t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalExitFlushAll$")
+	cmd.Env = append(os.Environ(), "LOG_TEST_FATAL_DIR="+dir)
+	out, err := cmd.CombinedOutput()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 || strings.Contains(string(out), "Fatal returned") {
+		t.Fatalf("unexpected exit %v: %s", err, out)
+	}
+	for name, want := range map[string]string{"app.log": "fatal", "other.log": "other"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil || !strings.Contains(string(data), want) {
+			t.Fatalf("%s not flushed: %q %v", name, data, err)
+		}
+	}
+}
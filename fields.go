@@ -0,0 +1,78 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const badKey = "!BADKEY"
+
+// 结构化字段(终端和文本文件中输出为key=value)
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// 创建结构化字段
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// 创建子日志对象(默认日志对象), 参数格式: key1, value1, key2, value2...
+func With(keysAndValues ...interface{}) *Logger {
+	return defaultLogger.With(keysAndValues...)
+}
+
+// 将key/value参数列表转换为字段(参数可以直接是Field类型, 缺少key的值使用!BADKEY作为key)
+func makeFields(keysAndValues ...interface{}) (fields []Field) {
+	for i := 0; i < len(keysAndValues); i++ {
+		switch v := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, v)
+		case string:
+			if i+1 == len(keysAndValues) {
+				fields = append(fields, Field{Key: badKey, Value: v})
+			} else {
+				fields = append(fields, Field{Key: v, Value: keysAndValues[i+1]})
+				i++
+			}
+		default:
+			fields = append(fields, Field{Key: badKey, Value: v})
+		}
+	}
+	return
+}
+
+// 字段格式化为 key1=value1 key2=value2(key中的空格、等号、引号和控制字符替换为下划线)
+func fmtFields(fields []Field) string {
+	var strFields []string
+	for _, f := range fields {
+		strFields = append(strFields, logfmtKey(f.Key)+"="+fmtFieldValue(f.Value))
+	}
+	return strings.Join(strFields, " ")
+}
+
+// 字段值格式化(含空格、引号、等号或控制字符时加引号转义)
+func fmtFieldValue(value interface{}) string {
+	var strValue string
+	switch v := value.(type) {
+	case string:
+		strValue = v
+	case error:
+		strValue = v.Error()
+	default:
+		strValue = fmt.Sprintf("%+v", v)
+	}
+	if strValue == "" {
+		return `""`
+	}
+	if strings.IndexFunc(strValue, needQuote) != -1 {
+		return strconv.Quote(strValue)
+	}
+	return strValue
+}
+
+func needQuote(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+}
@@ -0,0 +1,484 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 日志文件输出(内置sink): 按文件大小或时间分割并清理过期备份
+type fileSink struct {
+	locker   sync.RWMutex
+	opened   bool           //已打开(日志文件对象为nil时写入前重新打开)
+	logFile  *os.File       //日志文件对象
+	option   *Option        //日志参数选项(文件路径、分割大小、备份数量、输出格式)
+	quit     chan struct{}  //通知日志文件维护协程退出
+	clean    chan struct{}  //通知日志文件维护协程清理过期备份
+	size     int64          //当前日志文件大小(按大小分割)
+	period   time.Time      //当前文件所属分割周期的开始时间(按时间分割)
+	rotateAt time.Time      //下次按时间分割的时间点
+	wg       sync.WaitGroup //日志文件维护协程和后台压缩协程
+	enc      atomic.Value   //编码器(encoderBox, 选项变化时重新创建)
+	compress compressQueue  //待压缩的备份文件
+}
+
+// 备份文件压缩队列(单个后台压缩协程依次压缩)
+type compressQueue struct {
+	locker  sync.Mutex
+	pending []compressTask  //等待压缩的备份文件
+	busy    map[string]bool //等待或正在压缩的备份文件路径(清理过期备份时跳过)
+	running bool            //后台压缩协程运行中
+}
+
+type compressTask struct {
+	path       string
+	compressor *Compressor
+}
+
+func newFileSink(opt *Option) *fileSink {
+	m := &fileSink{
+		option: opt,
+	}
+	m.updateEncoder()
+	return m
+}
+
+// 按当前选项重新创建编码器
+func (m *fileSink) updateEncoder() {
+	m.enc.Store(encoderBox{NewEncoder(m.option.Format, *m.option)})
+}
+
+func (m *fileSink) Write(r *Record) error {
+	m.locker.RLock()
+	opened := m.opened
+	m.locker.RUnlock()
+	if !opened {
+		return nil //未打开日志文件时不做编码
+	}
+	data := m.enc.Load().(encoderBox).Encode(r)
+	m.locker.Lock()
+	defer m.locker.Unlock()
+	if !m.opened {
+		return nil
+	}
+	if m.rotateByTime() && !r.Time.Before(m.rotateAt) {
+		_ = m.backupFile(m.periodSuffix(m.period))
+		m.period, m.rotateAt = m.rotatePeriod(r.Time)
+	}
+	//写入后超过分割大小时先备份当前文件
+	renameSize := int64(m.option.FileSize) * 1024 * 1024
+	if renameSize > 0 && m.size > 0 && m.size+int64(len(data)) > renameSize {
+		_ = m.backupFile(time.Now().Format("20060102150405"))
+	}
+	if m.logFile == nil {
+		//之前分割或重新打开时打开文件失败(如: 文件句柄耗尽、磁盘已满), 每次写入时重试
+		if err := m.openFile(); err != nil {
+			return err
+		}
+	}
+	n, err := m.logFile.Write(data)
+	m.size += int64(n)
+	return err
+}
+
+// 将日志文件内容同步到磁盘
+func (m *fileSink) Sync() error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+	if m.logFile == nil {
+		return nil
+	}
+	return m.logFile.Sync()
+}
+
+// 是否按时间分割日志文件
+func (m *fileSink) rotateByTime() bool {
+	return m.option.RotateAtMidnight || m.option.RotateEvery > 0
+}
+
+// 计算t所在分割周期的开始和结束时间(按本地时间对齐)
+func (m *fileSink) rotatePeriod(t time.Time) (begin, end time.Time) {
+	if m.option.RotateAtMidnight {
+		y, mon, d := t.Date()
+		begin = time.Date(y, mon, d, 0, 0, 0, 0, t.Location())
+		return begin, begin.AddDate(0, 0, 1)
+	}
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	begin = t.Add(shift).Truncate(m.option.RotateEvery).Add(-shift)
+	return begin, begin.Add(m.option.RotateEvery)
+}
+
+// 按时间分割的备份文件后缀(与分割周期匹配, 如: app.log.2026-10-17)
+func (m *fileSink) periodSuffix(begin time.Time) string {
+	every := m.option.RotateEvery
+	switch {
+	case m.option.RotateAtMidnight || every%RotateDaily == 0:
+		return begin.Format("2006-01-02")
+	case every%RotateHourly == 0:
+		return begin.Format("2006-01-02T15")
+	case every%time.Minute == 0:
+		return begin.Format("2006-01-02T15-04")
+	default:
+		return begin.Format("2006-01-02T15-04-05")
+	}
+}
+
+// 将当前日志文件备份为"文件名.后缀"并重新创建(备份文件已存在时追加序号), 调用者需持有锁
+// 重新创建失败时日志文件对象为nil, 下次写入时重试
+func (m *fileSink) backupFile(strSuffix string) (err error) {
+	if m.logFile != nil {
+		_ = m.logFile.Close()
+		m.logFile = nil
+	}
+	strPath := fmt.Sprintf("%v.%v", m.option.filePath, strSuffix) //日志文件有后缀(日志备份文件名格式不能随意改动)
+	for i := 1; ; i++ {
+		if !backupExists(strPath) {
+			break
+		}
+		strPath = fmt.Sprintf("%v.%v.%v", m.option.filePath, strSuffix, i)
+	}
+	err = os.Rename(m.option.filePath, strPath) //将文件备份
+	if err == nil {
+		m.compressBackup(strPath)
+		m.triggerClean()
+	}
+	if errOpen := m.openFile(); errOpen != nil {
+		return errOpen
+	}
+	return err
+}
+
+// 压缩上次运行遗留的未压缩备份, 删除未完成的压缩临时文件, 调用者需持有锁
+func (m *fileSink) compressPending() {
+	c := getCompressor(m.option.Compress)
+	if c == nil {
+		return
+	}
+	dir, filename := filepath.Split(m.option.filePath)
+	if dir == "" {
+		dir = "."
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, filename+".") {
+			continue
+		}
+		strPath := filepath.Join(dir, name)
+		strSuffix := strings.TrimPrefix(name, filename+".")
+		if strings.HasSuffix(name, compressTempExt) && isBackupSuffix(strings.TrimSuffix(strSuffix, compressTempExt)) {
+			if !m.compressing(trimCompressExt(strings.TrimSuffix(strPath, compressTempExt))) {
+				_ = os.Remove(strPath)
+			}
+		} else if trimCompressExt(name) == name && isBackupSuffix(strSuffix) {
+			m.compressBackup(strPath)
+		}
+	}
+}
+
+// 备份文件(含压缩后的文件)是否已存在
+func backupExists(strPath string) bool {
+	if _, err := os.Stat(strPath); !os.IsNotExist(err) {
+		return true
+	}
+	compressLocker.RLock()
+	defer compressLocker.RUnlock()
+	for _, c := range compressors {
+		if _, err := os.Stat(strPath + c.Ext); !os.IsNotExist(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// 备份文件加入压缩队列(Option.Compress), 由单个后台压缩协程依次压缩, 调用者需持有锁
+func (m *fileSink) compressBackup(strPath string) {
+	if m.option.Compress == "" {
+		return
+	}
+	c := getCompressor(m.option.Compress)
+	if c == nil {
+		return
+	}
+	strPath = filepath.Clean(strPath)
+	q := &m.compress
+	q.locker.Lock()
+	defer q.locker.Unlock()
+	if q.busy[strPath] {
+		return
+	}
+	if q.busy == nil {
+		q.busy = make(map[string]bool)
+	}
+	q.busy[strPath] = true
+	q.pending = append(q.pending, compressTask{path: strPath, compressor: c})
+	if !q.running {
+		q.running = true
+		m.wg.Add(1)
+		go m.compressWorker()
+	}
+}
+
+// 后台压缩协程: 压缩队列中的备份文件, 队列为空时退出
+func (m *fileSink) compressWorker() {
+	defer m.wg.Done()
+	q := &m.compress
+	for {
+		q.locker.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.locker.Unlock()
+			return
+		}
+		task := q.pending[0]
+		q.pending = q.pending[1:]
+		q.locker.Unlock()
+		err := compressFile(task.path, task.compressor)
+		q.locker.Lock()
+		delete(q.busy, task.path)
+		q.locker.Unlock()
+		if err == nil {
+			m.locker.Lock()
+			m.triggerClean()
+			m.locker.Unlock()
+		}
+	}
+}
+
+// 备份文件是否等待或正在压缩
+func (m *fileSink) compressing(strPath string) bool {
+	m.compress.locker.Lock()
+	defer m.compress.locker.Unlock()
+	return m.compress.busy[filepath.Clean(strPath)]
+}
+
+// 打开日志文件并启动日志文件维护协程(重复打开不会启动多个维护协程)
+func (m *fileSink) open() error {
+	if err := m.createFile(); err != nil {
+		return err
+	}
+	m.locker.Lock()
+	defer m.locker.Unlock()
+	m.opened = true
+	m.compressPending()
+	if m.quit == nil {
+		m.quit = make(chan struct{})
+		m.clean = make(chan struct{}, 1)
+		m.wg.Add(1)
+		go m.maintain(m.quit, m.clean)
+	}
+	m.triggerClean()
+	return nil
+}
+
+// 关闭日志文件, 停止日志文件维护协程并等待后台压缩完成
+func (m *fileSink) close() (err error) {
+	m.locker.Lock()
+	m.opened = false
+	if m.quit != nil {
+		close(m.quit)
+		m.quit = nil
+	}
+	if m.logFile != nil {
+		err = m.logFile.Close()
+		m.logFile = nil
+	}
+	m.locker.Unlock()
+	m.wg.Wait()
+	return err
+}
+
+// 日志文件维护协程: 日志分割后清理过期备份
+func (m *fileSink) maintain(quit, clean chan struct{}) {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-clean:
+			_ = m.cleanBackupLog()
+		}
+	}
+}
+
+// 通知维护协程清理过期备份(不阻塞), 调用者需持有锁
+func (m *fileSink) triggerClean() {
+	if m.clean == nil {
+		return
+	}
+	select {
+	case m.clean <- struct{}{}:
+	default:
+	}
+}
+
+// 备份文件(压缩前后的文件计为同一个备份)
+type backupInfo struct {
+	path    string    //备份文件路径(压缩前)
+	files   []string  //备份文件路径
+	size    int64     //文件总大小
+	modTime time.Time //最后修改时间
+}
+
+// 备份文件后缀时间格式(按大小分割/按时间分割)
+var backupTimeLayouts = []string{
+	"20060102150405",
+	"2006-01-02",
+	"2006-01-02T15",
+	"2006-01-02T15-04",
+	"2006-01-02T15-04-05",
+}
+
+// 是否为日志文件的备份后缀: 时间[.序号][压缩扩展名]
+func isBackupSuffix(strSuffix string) bool {
+	strSuffix = trimCompressExt(strSuffix)
+	if idx := strings.LastIndex(strSuffix, "."); idx > 0 {
+		if _, err := strconv.Atoi(strSuffix[idx+1:]); err == nil {
+			strSuffix = strSuffix[:idx]
+		}
+	}
+	for _, layout := range backupTimeLayouts {
+		if _, err := time.Parse(layout, strSuffix); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// 列出日志文件所在目录(不含子目录)中的全部备份, 按修改时间从新到旧排序(压缩中的临时文件不计入)
+func (m *fileSink) listBackups() (backups []*backupInfo) {
+	dir, filename := filepath.Split(m.option.filePath)
+	if dir == "" {
+		dir = "."
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	groups := make(map[string]*backupInfo)
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, filename+".") || strings.HasSuffix(name, compressTempExt) {
+			continue
+		}
+		if !isBackupSuffix(strings.TrimPrefix(name, filename+".")) {
+			continue
+		}
+		key := trimCompressExt(name)
+		b, ok := groups[key]
+		if !ok {
+			b = &backupInfo{path: filepath.Join(dir, key)}
+			groups[key] = b
+			backups = append(backups, b)
+		}
+		b.files = append(b.files, filepath.Join(dir, name))
+		b.size += fi.Size()
+		if fi.ModTime().After(b.modTime) {
+			b.modTime = fi.ModTime()
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+	return
+}
+
+// 清理过期备份: 超过MaxBackups个数、早于MaxAge天或总大小超过MaxTotalSize(MB)的旧备份
+func (m *fileSink) cleanBackupLog() error {
+	if m.option.filePath == "" {
+		return nil
+	}
+	var total int64
+	expireTime := time.Now().AddDate(0, 0, -m.option.MaxAge)
+	maxTotalSize := int64(m.option.MaxTotalSize) * 1024 * 1024
+	for i, b := range m.listBackups() {
+		total += b.size
+		if (m.option.MaxBackups > 0 && i >= m.option.MaxBackups) ||
+			(m.option.MaxAge > 0 && b.modTime.Before(expireTime)) ||
+			(maxTotalSize > 0 && total > maxTotalSize) {
+			m.removeBackup(b)
+		}
+	}
+	return nil
+}
+
+// 删除备份文件(等待或正在压缩的备份不删除, 持有压缩队列锁期间不会开始压缩该备份)
+func (m *fileSink) removeBackup(b *backupInfo) {
+	q := &m.compress
+	q.locker.Lock()
+	defer q.locker.Unlock()
+	if q.busy[b.path] {
+		return
+	}
+	for _, strFilePath := range b.files {
+		_ = os.Remove(strFilePath)
+	}
+}
+
+// 创建日志文件
+func (m *fileSink) createFile() error {
+	var err error
+	//检查目录路径是否存在，不存在自动创建目录和子目录
+	dir := getDirFromPath(m.option.filePath)
+	err = createDirIfNotExist(dir)
+	if err != nil {
+		return err
+	}
+	m.locker.Lock()
+	defer m.locker.Unlock()
+	if m.logFile != nil {
+		_ = m.logFile.Close()
+	}
+	return m.openFile()
+}
+
+// 重新打开日志文件(外部logrotate重命名日志文件后调用), 持有锁期间不会丢失日志
+// 之前打开失败时同样重新打开(未设置日志文件路径时忽略)
+func (m *fileSink) reopen() error {
+	m.locker.Lock()
+	defer m.locker.Unlock()
+	if m.option.filePath == "" {
+		return nil
+	}
+	if m.logFile != nil {
+		_ = m.logFile.Close()
+		m.logFile = nil
+	}
+	if err := createDirIfNotExist(getDirFromPath(m.option.filePath)); err != nil {
+		return err
+	}
+	return m.openFile()
+}
+
+// 打开日志文件并初始化文件大小和分割周期, 调用者需持有锁
+func (m *fileSink) openFile() (err error) {
+	m.logFile, err = os.OpenFile(m.option.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		m.logFile = nil
+		return fmt.Errorf("open log file %s failed %s", m.option.filePath, err)
+	}
+	now := time.Now()
+	m.size = 0
+	if fi, err := m.logFile.Stat(); err == nil {
+		m.size = fi.Size()
+		if fi.Size() > 0 {
+			now = fi.ModTime()
+		}
+	}
+	if m.rotateByTime() {
+		//已有日志内容的文件按最后修改时间确定所属周期(如: 进程跨天重启)
+		m.period, m.rotateAt = m.rotatePeriod(now)
+	}
+	return nil
+}
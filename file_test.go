@@ -0,0 +1,292 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 创建临时目录中的日志文件输出(测试结束时关闭)
+func newTestFileSink(t *testing.T, opt Option) *fileSink {
+	t.Helper()
+	opt.filePath = filepath.Join(t.TempDir(), "app.log")
+	m := newFileSink(&opt)
+	if err := m.open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = m.close()
+	})
+	return m
+}
+
+// 列出目录中的文件名(排序)
+func listDir(t *testing.T, dir string) (names []string) {
+	t.Helper()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range files {
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+	return
+}
+
+func TestFileSinkRotateBySize(t *testing.T) {
+	m := newTestFileSink(t, Option{FileSize: 1})
+	msg := strings.Repeat("x", 600*1024)
+	for i := 0; i < 3; i++ {
+		if err := m.Write(&Record{Time: time.Now(), Level: LEVEL_INFO, Msg: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := listDir(t, filepath.Dir(m.option.filePath))
+	if len(names) != 3 {
+		t.Fatalf("expect log file and 2 backups, got %v", names)
+	}
+	for _, name := range names[1:] {
+		if !isBackupSuffix(strings.TrimPrefix(name, "app.log.")) {
+			t.Errorf("unexpected backup name %s", name)
+		}
+	}
+}
+
+func TestFileSinkRotateByTime(t *testing.T) {
+	m := newTestFileSink(t, Option{RotateEvery: RotateHourly})
+	now := time.Now()
+	begin, _ := m.rotatePeriod(now)
+	for _, ts := range []time.Time{now, now.Add(time.Hour), now.Add(2 * time.Hour)} {
+		if err := m.Write(&Record{Time: ts, Level: LEVEL_INFO, Msg: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"app.log",
+		"app.log." + begin.Format("2006-01-02T15"),
+		"app.log." + begin.Add(time.Hour).Format("2006-01-02T15"),
+	}
+	sort.Strings(want)
+	if got := listDir(t, filepath.Dir(m.option.filePath)); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFileSinkRetryOpen(t *testing.T) {
+	m := newTestFileSink(t, Option{})
+	strPath := m.option.filePath
+	//模拟分割后重新创建日志文件失败
+	m.locker.Lock()
+	_ = m.logFile.Close()
+	m.logFile = nil
+	m.locker.Unlock()
+	if err := os.Remove(strPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(strPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Write(&Record{Time: time.Now(), Level: LEVEL_INFO, Msg: "lost"}); err == nil {
+		t.Fatal("expect open error")
+	}
+	if err := os.Remove(strPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Write(&Record{Time: time.Now(), Level: LEVEL_INFO, Msg: "resumed"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(strPath)
+	if err != nil || !strings.Contains(string(data), "resumed") {
+		t.Fatalf("logging not resumed: %q %v", data, err)
+	}
+}
+
+func TestIsBackupSuffix(t *testing.T) {
+	cases := []struct {
+		suffix string
+		want   bool
+	}{
+		{"20261017010203", true},
+		{"2026-10-17", true},
+		{"2026-10-17T15", true},
+		{"2026-10-17T15-04", true},
+		{"2026-10-17T15-04-05", true},
+		{"2026-10-17.1", true},
+		{"20261017010203.12", true},
+		{"2026-10-17.gz", true},
+		{"2026-10-17.3.gz", true},
+		{"bak", false},
+		{"1", false},
+		{"old", false},
+		{"2026-10-17.bak", false},
+		{"2026-10-17.gz.tmp", false},
+		{"2026-13-17", false},
+		{"20261017", false},
+		{"2026-10-17.1.2", false},
+		{"x.2026-10-17", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := isBackupSuffix(c.suffix); got != c.want {
+			t.Errorf("isBackupSuffix(%q) = %v, want %v", c.suffix, got, c.want)
+		}
+	}
+}
+
+// 测试备份文件(相对当前时间的修改时间和文件大小)
+type testBackup struct {
+	name string
+	age  time.Duration
+	size int64
+}
+
+// 在临时目录中创建备份文件并执行清理, 返回剩余的文件
+func cleanTestBackups(t *testing.T, opt Option, backups []testBackup) []string {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Now()
+	for _, b := range backups {
+		strPath := filepath.Join(dir, b.name)
+		if strings.HasSuffix(b.name, "/") {
+			if err := os.Mkdir(strPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(strPath, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Truncate(strPath, b.size); err != nil {
+			t.Fatal(err)
+		}
+		ts := now.Add(-b.age)
+		if err := os.Chtimes(strPath, ts, ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opt.filePath = filepath.Join(dir, "app.log")
+	m := newFileSink(&opt)
+	if err := m.cleanBackupLog(); err != nil {
+		t.Fatal(err)
+	}
+	return listDir(t, dir)
+}
+
+func TestCleanBackupLog(t *testing.T) {
+	const mb = 1024 * 1024
+	day := 24 * time.Hour
+	unrelated := []testBackup{
+		{name: "app.log", age: 0},
+		{name: "app.log.bak", age: 100 * day},
+		{name: "app.log.2026-01-01/", age: 100 * day},
+		{name: "app.log.2026-01-02.gz.tmp", age: 100 * day},
+		{name: "other.log.2026-01-03", age: 100 * day},
+	}
+	cases := []struct {
+		name    string
+		opt     Option
+		backups []testBackup
+		want    []string
+	}{
+		{
+			name: "max backups",
+			opt:  Option{MaxBackups: 2},
+			backups: []testBackup{
+				{name: "app.log.2026-10-17", age: 1 * day},
+				{name: "app.log.2026-10-16", age: 2 * day},
+				{name: "app.log.2026-10-16.1", age: 3 * day},
+				{name: "app.log.2026-10-15.gz", age: 4 * day},
+			},
+			want: []string{"app.log.2026-10-16", "app.log.2026-10-17"},
+		},
+		{
+			name: "compressed and uncompressed count as one backup",
+			opt:  Option{MaxBackups: 2},
+			backups: []testBackup{
+				{name: "app.log.2026-10-17", age: 1 * day},
+				{name: "app.log.2026-10-16", age: 2 * day},
+				{name: "app.log.2026-10-16.gz", age: 2 * day},
+				{name: "app.log.2026-10-15.gz", age: 3 * day},
+			},
+			want: []string{"app.log.2026-10-16", "app.log.2026-10-16.gz", "app.log.2026-10-17"},
+		},
+		{
+			name: "max age",
+			opt:  Option{MaxAge: 7},
+			backups: []testBackup{
+				{name: "app.log.2026-10-17", age: 1 * day},
+				{name: "app.log.2026-10-11", age: 6 * day},
+				{name: "app.log.2026-10-09", age: 8 * day},
+				{name: "app.log.20261001000000.gz", age: 16 * day},
+			},
+			want: []string{"app.log.2026-10-11", "app.log.2026-10-17"},
+		},
+		{
+			name: "max total size",
+			opt:  Option{MaxTotalSize: 3},
+			backups: []testBackup{
+				{name: "app.log.2026-10-17", age: 1 * day, size: 1 * mb},
+				{name: "app.log.2026-10-16", age: 2 * day, size: 2 * mb},
+				{name: "app.log.2026-10-15", age: 3 * day, size: 1},
+				{name: "app.log.2026-10-14", age: 4 * day, size: 1 * mb},
+			},
+			want: []string{"app.log.2026-10-16", "app.log.2026-10-17"},
+		},
+		{
+			name: "no limit",
+			opt:  Option{},
+			backups: []testBackup{
+				{name: "app.log.2026-10-17", age: 1 * day},
+				{name: "app.log.2020-10-16", age: 2000 * day},
+			},
+			want: []string{"app.log.2020-10-16", "app.log.2026-10-17"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := cleanTestBackups(t, c.opt, append(c.backups, unrelated...))
+			want := []string{"app.log", "app.log.2026-01-01", "app.log.2026-01-02.gz.tmp", "app.log.bak", "other.log.2026-01-03"}
+			want = append(want, c.want...)
+			sort.Strings(want)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileSinkReopen(t *testing.T) {
+	m := newTestFileSink(t, Option{})
+	strPath := m.option.filePath
+	//模拟外部logrotate重命名日志文件, 并且重新打开失败
+	if err := os.Rename(strPath, strPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(strPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.reopen(); err == nil {
+		t.Fatal("expect reopen error")
+	}
+	if err := os.Remove(strPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if m.logFile == nil {
+		t.Fatal("log file not reopened after a failed reopen")
+	}
+	if err := m.Write(&Record{Time: time.Now(), Level: LEVEL_INFO, Msg: "reopened"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(strPath)
+	if err != nil || !strings.Contains(string(data), "reopened") {
+		t.Fatalf("log file not reopened: %q %v", data, err)
+	}
+}
@@ -5,5 +5,5 @@ go 1.13
 require (
 	github.com/civet148/gotools v1.4.1
 	github.com/fatih/color v1.12.0
-	github.com/mattn/go-colorable v0.1.8 // indirect
+	github.com/mattn/go-colorable v0.1.8
 )
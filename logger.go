@@ -0,0 +1,682 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 日志对象: 每个对象拥有独立的日志文件、日志级别、显示选项以及文件分割/关闭生命周期
+type Logger struct {
+	*logInfo              //日志输出对象(With创建的子日志对象与父对象共享)
+	fields   []Field      //结构化字段(每行日志都会输出)
+	skip     int          //获取调用位置时额外跳过的调用层数(封装日志函数时使用)
+	scope    *scopeBuffer //范围日志缓存(Scope创建)
+}
+
+type logInfo struct {
+	dropped    uint64 //异步输出丢弃的日志数量(原子操作, 保持64位对齐)
+	locker     sync.Mutex
+	option     Option            //日志参数选项
+	console    *consoleSink      //终端屏幕输出
+	file       *fileSink         //日志文件输出
+	levelFiles map[int]*fileSink //分级日志文件输出(如: error.log)
+	sinks      sinkList          //日志输出列表
+	level      int32             //日志级别(原子操作, 与option.LogLevel一致)
+	minLevel   int32             //需要处理的最低日志级别(原子操作, 开启最近日志记录时为LEVEL_TRACE)
+	signals    chan os.Signal    //重新打开日志文件的信号
+	async      atomic.Value      //异步输出(*asyncWriter)
+	recent     atomic.Value      //最近日志记录(*recorder)
+}
+
+func newLogger(opt Option) *Logger {
+	inf := &logInfo{}
+	inf.console = newConsoleSink(&inf.option)
+	inf.file = newFileSink(&inf.option)
+	inf.sinks.add(SinkConsole, inf.console, LEVEL_TRACE)
+	inf.sinks.add(SinkFile, inf.file, LEVEL_TRACE)
+	l := &Logger{
+		logInfo: inf,
+	}
+	l.setOption(opt)
+	registerLogger(inf)
+	return l
+}
+
+// 创建子日志对象, 子对象输出的每行日志都附带keysAndValues字段(key1, value1, key2, value2...)
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	fields := make([]Field, 0, len(l.fields)+len(keysAndValues)/2)
+	fields = append(fields, l.fields...)
+	return &Logger{
+		logInfo: l.logInfo,
+		fields:  append(fields, makeFields(keysAndValues...)...),
+		skip:    l.skip,
+		scope:   l.scope,
+	}
+}
+
+// 创建子日志对象, 获取调用位置时额外跳过n层调用(用于封装日志函数的辅助函数)
+func (l *Logger) AddCallerSkip(n int) *Logger {
+	return &Logger{
+		logInfo: l.logInfo,
+		fields:  l.fields,
+		skip:    l.skip + n,
+		scope:   l.scope,
+	}
+}
+
+// 创建日志对象(filePath为空时仅输出到终端屏幕)
+func New(filePath string, opts ...Option) (*Logger, error) {
+	l := newLogger(Option{
+		LogLevel:   LEVEL_INFO,
+		FileSize:   DefaultLogSize,
+		MaxBackups: DefaultMaxBackups,
+		ShowCaller: true,
+	})
+	if len(opts) > 0 {
+		l.setOption(opts[0])
+	}
+	if filePath == "" {
+		l.applyAsync()
+		return l, nil
+	}
+	if err := l.Open(filePath, opts...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// 获取默认日志对象(包级别函数均输出到此对象)
+func Default() *Logger {
+	return defaultLogger
+}
+
+func (l *Logger) ShowProcess() {
+	l.option.ShowProcess = true
+	l.updateEncoders()
+}
+
+func (l *Logger) ShowRoutine() {
+	l.option.ShowRoutine = true
+	l.updateEncoders()
+}
+
+func (l *Logger) DisableCaller() {
+	l.option.ShowCaller = false
+	l.updateEncoders()
+}
+
+// 设置日志参数选项
+func (l *Logger) setOption(opt Option) {
+	l.option = opt
+	l.applyRecent()
+	l.storeLevel()
+	l.updateEncoders()
+}
+
+// 按当前选项重新创建内置输出的编码器(显示选项或输出格式变化后调用)
+func (l *Logger) updateEncoders() {
+	l.console.updateEncoder()
+	l.file.updateEncoder()
+}
+
+// 更新原子日志级别(开启最近日志记录时需要处理全部级别)
+func (l *Logger) storeLevel() {
+	level := l.option.LogLevel
+	atomic.StoreInt32(&l.level, int32(level))
+	if l.option.RecentSize > 0 {
+		level = LEVEL_TRACE
+	}
+	atomic.StoreInt32(&l.minLevel, int32(level))
+}
+
+// 日志级别是否开启(可在输出开销较大的日志前判断)
+func (l *Logger) IsEnabled(level int) bool {
+	return l.enabled(level)
+}
+
+// 当前日志级别(原子读取, 可与SetLevel并发调用)
+func (l *Logger) logLevel() int {
+	return int(atomic.LoadInt32(&l.level))
+}
+
+// 日志级别是否开启(不低于日志级别或有未提交的范围日志)
+func (l *Logger) enabled(level int) bool {
+	if level >= l.logLevel() {
+		return true
+	}
+	return l.scope != nil && l.scope.active()
+}
+
+// 日志是否需要处理(输出、缓存到范围日志或保存到最近日志记录), 日志函数在格式化参数前判断
+func (l *Logger) handled(level int) bool {
+	if level >= int(atomic.LoadInt32(&l.minLevel)) {
+		return true
+	}
+	return l.scope != nil && l.scope.active()
+}
+
+// 打开日志文件并启动日志文件维护协程
+func (l *Logger) Open(filePath string, opts ...Option) error {
+	err := l.openWithOptions(filePath, opts...)
+	if err != nil {
+		return l.Errorf("%s", err)
+	}
+	return nil
+}
+
+// 关闭日志(写出异步队列中剩余的日志)
+func (l *Logger) Close() {
+	l.locker.Lock()
+	if a := l.loadAsync(); a != nil {
+		l.async.Store((*asyncWriter)(nil))
+		a.stop()
+	}
+	l.stopSignal()
+	unregisterLogger(l.logInfo)
+	files := l.levelFiles
+	l.levelFiles = nil
+	l.locker.Unlock()
+	for level, f := range files {
+		l.RemoveSink(levelSinkName(level))
+		_ = f.close()
+	}
+	err := l.file.close()
+	if err != nil {
+		l.Errorf("%s", err)
+		return
+	}
+}
+
+// 打开分级日志文件: level及以上级别的日志(JSON除外)同时输出到该文件, 如: OpenLevelFile(LEVEL_ERROR, "logs/error.log")
+// opts为该文件的分割大小、备份数量等选项, 不指定时使用日志对象的选项
+func (l *Logger) OpenLevelFile(level int, filePath string, opts ...Option) error {
+	if filePath == "" {
+		return l.Errorf("log file path is required")
+	}
+	opt := l.option
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if err := checkCompressor(opt.Compress); err != nil {
+		return l.Errorf("%s", err)
+	}
+	opt.filePath = filePath
+	if opt.FileSize == 0 {
+		opt.FileSize = DefaultLogSize
+	}
+	if opt.MaxBackups == 0 {
+		opt.MaxBackups = DefaultMaxBackups
+	}
+	f := newFileSink(&opt)
+	if err := f.open(); err != nil {
+		return l.Errorf("%s", err)
+	}
+	l.locker.Lock()
+	old := l.levelFiles[level]
+	if l.levelFiles == nil {
+		l.levelFiles = make(map[int]*fileSink)
+	}
+	l.levelFiles[level] = f
+	l.locker.Unlock()
+	l.AddSink(levelSinkName(level), SinkFunc(func(r *Record) error {
+		if r.Level == LEVEL_JSON {
+			return nil
+		}
+		return f.Write(r)
+	}), level)
+	if old != nil {
+		_ = old.close()
+	}
+	return nil
+}
+
+// 分级日志文件输出名称(如: file.error)
+func levelSinkName(level int) string {
+	return SinkFile + "." + levelString(level)
+}
+
+// 重新打开日志文件和分级日志文件(配合外部logrotate使用), 返回第一个打开失败的错误
+func (l *Logger) Reopen() error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	err := l.file.reopen()
+	for _, f := range l.levelFiles {
+		if e := f.reopen(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// 收到指定信号时重新打开日志文件, 如: ReopenOnSignal(syscall.SIGHUP)
+// 重复调用时替换之前注册的信号, 关闭日志时停止
+func (l *Logger) ReopenOnSignal(sigs ...os.Signal) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	l.stopSignal()
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	l.signals = ch
+	go func() {
+		for range ch {
+			_ = l.Reopen()
+		}
+	}()
+}
+
+// 停止接收重新打开日志文件的信号, 调用者需持有锁
+func (l *Logger) stopSignal() {
+	if l.signals != nil {
+		signal.Stop(l.signals)
+		close(l.signals)
+		l.signals = nil
+	}
+}
+
+// 设置日志文件分割大小（MB)
+func (l *Logger) SetFileSize(size int) {
+	l.option.FileSize = size
+}
+
+// 设置日志级别(字符串型: trace/debug/info/warn/error/fatal 数值型: 0=TRACE 1 =DEBUG 2=INFO 3=WARN 4=ERROR 5=FATAL)
+func (l *Logger) SetLevel(level interface{}) {
+	l.option.LogLevel = parseLevel(level)
+	l.storeLevel()
+}
+
+// 设置关闭/开启屏幕输出
+func (l *Logger) CloseConsole(ok bool) {
+	l.option.CloseConsole = ok
+}
+
+// 设置最大备份数量
+func (l *Logger) SetMaxBackup(nMaxBackups int) {
+	l.option.MaxBackups = nMaxBackups
+}
+
+func (l *Logger) openWithOptions(filePath string, opts ...Option) (err error) {
+	if filePath == "" {
+		return fmt.Errorf("log file path is required")
+	}
+	opt := l.option
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if err = checkCompressor(opt.Compress); err != nil {
+		return err //未注册的压缩算法会使备份文件不被压缩
+	}
+	if len(opts) > 0 {
+		l.setOption(opts[0])
+	}
+	l.option.filePath = filePath
+	if l.option.FileSize == 0 {
+		l.option.FileSize = DefaultLogSize
+	}
+	if l.option.MaxBackups == 0 {
+		l.option.MaxBackups = DefaultMaxBackups
+	}
+	l.applyAsync()
+	registerLogger(l.logInfo)
+	return l.file.open() //创建文件
+}
+
+// 内部格式化输出函数
+func (l *Logger) output(level int, formatter interface{}, args ...interface{}) (strFile, strFunc string, nLineNo int) {
+	if !l.handled(level) {
+		return
+	}
+	if !l.enabled(level) {
+		//低于日志级别的日志只保存到最近日志记录
+		l.recordRecent(level, 3+l.skip, formatter, args)
+		return
+	}
+	msg := fmtMessage(formatter, args)
+	fields := l.fields
+	var stack []string
+	if e, ok := formatter.(loggedError); ok {
+		strFile, strFunc, nLineNo = e.File, e.Func, e.Line
+		stack, fields = e.Stack, e.Fields
+	} else {
+		if c := getCallerInfo(3 + l.skip); c != nil {
+			strFile, strFunc = c.format(l.option.CallerFormat)
+			nLineNo = c.line
+		}
+		if depth := l.stackDepth(level); depth > 0 {
+			stack = getStack(3+l.skip, depth, l.option.CallerFormat)
+		}
+		if err, ok := formatter.(error); ok {
+			fields = errorFields(fields, err)
+		}
+	}
+	r := &Record{
+		Time:       time.Now(),
+		Level:      level,
+		File:       strFile,
+		Func:       strFunc,
+		Line:       nLineNo,
+		Routine:    getRoutineId(),
+		PID:        os.Getpid(),
+		Msg:        msg,
+		Fields:     fields,
+		Stack:      stack,
+		Goroutines: l.goroutineStacks(level),
+	}
+	l.dispatch(r)
+	return
+}
+
+// 输出日志记录(output和slog共用): 缓存到范围日志, ERROR及以上级别先输出最近日志记录, FATAL按选项退出进程
+func (l *Logger) dispatch(r *Record) {
+	level := r.Level
+	if level == LEVEL_FATAL && l.option.FatalExit {
+		defer l.exit()
+	}
+	below := level < l.logLevel()
+	if l.scope != nil {
+		records, write := l.scope.add(r, below)
+		for _, sr := range records {
+			l.emit(sr) //先按顺序输出缓存的低于日志级别的日志
+		}
+		below = !write
+	}
+	if below {
+		return //已缓存到范围日志或范围日志已提交
+	}
+	if level >= LEVEL_ERROR && level != LEVEL_JSON {
+		l.DumpRecent() //先输出错误发生前的最近日志记录
+	}
+	l.emit(r)
+	l.addRecent(recentItem{written: true})
+}
+
+// 格式化日志内容
+func fmtMessage(formatter interface{}, args []interface{}) (msg string) {
+	switch formatter.(type) {
+	case argsFormat:
+		if formatter.(argsFormat) == fmtArgsW {
+			msg = fmtStringW(args...)
+		} else {
+			msg = fmtString(args...)
+		}
+	case string:
+		fmtstr := formatter.(string)
+		if fmtstr != "" {
+			msg = fmt.Sprintf(fmtstr, args...)
+		} else {
+			msg = fmt.Sprint(args...)
+		}
+	case loggedError:
+		msg = formatter.(loggedError).Error()
+	case error:
+		msg = formatter.(error).Error()
+	}
+	return
+}
+
+// 按调用堆栈策略获取日志级别对应的调用堆栈层数(0表示不输出调用堆栈)
+func (l *Logger) stackDepth(level int) int {
+	minLevel := l.option.StackLevel
+	if minLevel == 0 && !l.option.StackLevelSet {
+		minLevel = LEVEL_ERROR
+	}
+	if level < minLevel || level == LEVEL_JSON || l.option.StackDepth < 0 {
+		return 0
+	}
+	if l.option.StackDepth == 0 {
+		return DefaultStackDepth
+	}
+	return l.option.StackDepth
+}
+
+// 全部协程的调用堆栈(FATAL级别且开启StackAllOnFatal)
+func (l *Logger) goroutineStacks(level int) string {
+	if level != LEVEL_FATAL || !l.option.StackAllOnFatal {
+		return ""
+	}
+	return getAllStacks()
+}
+
+// 输出日志记录(开启异步输出时加入队列)
+func (l *Logger) emit(r *Record) {
+	if a := l.loadAsync(); a != nil {
+		a.enqueue(r)
+		return
+	}
+	l.write(r)
+}
+
+// 输出日志记录到全部日志输出
+func (l *Logger) write(r *Record) {
+	for _, s := range l.sinks.load() {
+		if r.Level >= s.level {
+			_ = s.sink.Write(r)
+		}
+	}
+}
+
+// 输出调试级别信息
+func (l *Logger) Trace(args ...interface{}) {
+	if !l.handled(LEVEL_TRACE) {
+		return
+	}
+	l.output(LEVEL_TRACE, fmtArgs, args...)
+}
+
+// 输出调试级别信息
+func (l *Logger) Debug(args ...interface{}) {
+	if !l.handled(LEVEL_DEBUG) {
+		return
+	}
+	l.output(LEVEL_DEBUG, fmtArgs, args...)
+}
+
+// 输出运行级别信息
+func (l *Logger) Info(args ...interface{}) {
+	if !l.handled(LEVEL_INFO) {
+		return
+	}
+	l.output(LEVEL_INFO, fmtArgs, args...)
+}
+
+// 输出警告级别信息
+func (l *Logger) Warn(args ...interface{}) {
+	if !l.handled(LEVEL_WARN) {
+		return
+	}
+	l.output(LEVEL_WARN, fmtArgs, args...)
+}
+
+// 输出警告级别信息
+func (l *Logger) Warning(args ...interface{}) {
+	if !l.handled(LEVEL_WARN) {
+		return
+	}
+	l.output(LEVEL_WARN, fmtArgs, args...)
+}
+
+// 输出错误级别信息
+func (l *Logger) Error(args ...interface{}) error {
+	err := l.newError(LEVEL_ERROR, argsToError(args...))
+	stic.error(l.output(LEVEL_ERROR, loggedError{err}))
+	return err
+}
+
+// 输出危险级别信息
+func (l *Logger) Fatal(args ...interface{}) error {
+	err := l.newError(LEVEL_FATAL, argsToError(args...))
+	stic.error(l.output(LEVEL_FATAL, loggedError{err}))
+	return err
+}
+
+// panic
+func (l *Logger) Panic(args ...interface{}) {
+	if l.option.PanicLog {
+		l.output(LEVEL_PANIC, fmtArgs, args...)
+		l.Flush()
+	}
+	panic(fmt.Sprintf(fmtString(args...)))
+}
+
+// 输出调试级别信息
+func (l *Logger) Tracef(formatter interface{}, args ...interface{}) {
+	if !l.handled(LEVEL_TRACE) {
+		return
+	}
+	l.output(LEVEL_TRACE, formatter, args...)
+}
+
+// 输出调试级别信息
+func (l *Logger) Debugf(formatter interface{}, args ...interface{}) {
+	if !l.handled(LEVEL_DEBUG) {
+		return
+	}
+	l.output(LEVEL_DEBUG, formatter, args...)
+}
+
+// 输出运行级别信息
+func (l *Logger) Infof(formatter interface{}, args ...interface{}) {
+	if !l.handled(LEVEL_INFO) {
+		return
+	}
+	l.output(LEVEL_INFO, formatter, args...)
+}
+
+// 输出警告级别信息
+func (l *Logger) Warnf(formatter interface{}, args ...interface{}) {
+	if !l.handled(LEVEL_WARN) {
+		return
+	}
+	l.output(LEVEL_WARN, formatter, args...)
+}
+
+// 输出警告级别信息
+func (l *Logger) Warningf(formatter interface{}, args ...interface{}) {
+	if !l.handled(LEVEL_WARN) {
+		return
+	}
+	l.output(LEVEL_WARN, formatter, args...)
+}
+
+// 输出错误级别信息
+func (l *Logger) Errorf(formatter interface{}, args ...interface{}) error {
+	cause := formatterToError(formatter, args...)
+	if cause == nil {
+		return nil
+	}
+	err := l.newError(LEVEL_ERROR, cause)
+	stic.error(l.output(LEVEL_ERROR, loggedError{err}))
+	return err
+}
+
+// 输出危险级别信息
+func (l *Logger) Fatalf(formatter interface{}, args ...interface{}) error {
+	cause := formatterToError(formatter, args...)
+	if cause == nil {
+		return nil
+	}
+	err := l.newError(LEVEL_FATAL, cause)
+	stic.error(l.output(LEVEL_FATAL, loggedError{err}))
+	return err
+}
+
+// 输出Trace级别信息
+func (l *Logger) Tracew(args ...interface{}) {
+	if !l.handled(LEVEL_DEBUG) {
+		return
+	}
+	l.output(LEVEL_DEBUG, fmtArgsW, args...)
+}
+
+// 输出调试级别信息
+func (l *Logger) Debugw(args ...interface{}) {
+	if !l.handled(LEVEL_DEBUG) {
+		return
+	}
+	l.output(LEVEL_DEBUG, fmtArgsW, args...)
+}
+
+// 输出运行级别信息
+func (l *Logger) Infow(args ...interface{}) {
+	if !l.handled(LEVEL_INFO) {
+		return
+	}
+	l.output(LEVEL_INFO, fmtArgsW, args...)
+}
+
+// 输出警告级别信息
+func (l *Logger) Warnw(args ...interface{}) {
+	if !l.handled(LEVEL_WARN) {
+		return
+	}
+	l.output(LEVEL_WARN, fmtArgsW, args...)
+}
+
+// 输出警告级别信息
+func (l *Logger) Warningw(args ...interface{}) {
+	if !l.handled(LEVEL_WARN) {
+		return
+	}
+	l.output(LEVEL_WARN, fmtArgsW, args...)
+}
+
+// 输出错误级别信息
+func (l *Logger) Errorw(args ...interface{}) {
+	stic.error(l.output(LEVEL_ERROR, fmtArgsW, args...))
+}
+
+// 输出危险级别信息
+func (l *Logger) Fatalw(args ...interface{}) {
+	stic.error(l.output(LEVEL_FATAL, fmtArgsW, args...))
+}
+
+// panic
+func (l *Logger) Panicw(args ...interface{}) {
+	if l.option.PanicLog {
+		l.output(LEVEL_PANIC, fmtArgsW, args...)
+		l.Flush()
+	}
+	panic(fmt.Sprintf(fmtStringW(args...)))
+}
+
+func (l *Logger) Truncate(level, size int, fmtstr string, args ...interface{}) {
+	if !l.handled(level) {
+		return
+	}
+	l.output(level, fmtTruncate(size, fmtstr, args...))
+}
+
+// 进入方法（统计）
+func (l *Logger) Enter(args ...interface{}) {
+	l.output(LEVEL_INFO, "enter ", args...)
+	stic.enter(getCaller(2 + l.skip))
+}
+
+// 离开方法（统计）
+// 返回执行时间：h 时 m 分 s 秒 ms 毫秒 （必须先调用Enter方法才能正确统计执行时间）
+func (l *Logger) Leave() (h, m, s int, ms float32) {
+
+	if nSpendTime, ok := stic.leave(getCaller(2 + l.skip)); ok {
+		h, m, s, ms = getSpendTime(nSpendTime)
+		l.output(LEVEL_INFO, "leave (%vh %vm %vs %.3fms)", h, m, s, ms)
+	}
+	return
+}
+
+// 打印结构体JSON
+func (l *Logger) Json(args ...interface{}) {
+	l.output(LEVEL_JSON, fmtJson(args...))
+}
+
+// 打印结构体
+func (l *Logger) Struct(args ...interface{}) {
+	if !l.handled(LEVEL_DEBUG) {
+		return
+	}
+	for _, strLog := range fmtStructs(args...) {
+		l.output(LEVEL_DEBUG, strLog)
+	}
+}
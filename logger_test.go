@@ -0,0 +1,114 @@
+package log
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetLevelConcurrent(t *testing.T) {
+	l, err := New("", Option{LogLevel: LEVEL_INFO, CloseConsole: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.SetLevel(LEVEL_INFO + i%2)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Infof("info %d", i)
+			l.Debugf("debug %d", i)
+		}
+	}()
+	wg.Wait()
+	l.SetLevel(LEVEL_WARN)
+	if l.IsEnabled(LEVEL_INFO) || !l.IsEnabled(LEVEL_WARN) {
+		t.Fatalf("level not applied")
+	}
+}
+
+// 保存日志记录的内存输出
+type memorySink struct {
+	locker  sync.Mutex
+	records []*Record
+}
+
+func (s *memorySink) Write(r *Record) error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+// 已输出的日志记录
+func (s *memorySink) load() []*Record {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return append([]*Record(nil), s.records...)
+}
+
+// 已输出的日志内容
+func (s *memorySink) messages() []string {
+	var msgs []string
+	for _, r := range s.load() {
+		msgs = append(msgs, r.Msg)
+	}
+	return msgs
+}
+
+// 创建只输出到内存的日志对象
+func newMemoryLogger(t *testing.T, opt Option) (*Logger, *memorySink) {
+	t.Helper()
+	opt.CloseConsole = true
+	l, err := New("", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &memorySink{}
+	l.AddSink("memory", s, LEVEL_TRACE)
+	return l, s
+}
+
+func TestStackLevel(t *testing.T) {
+	cases := []struct {
+		name  string
+		opt   Option
+		level int
+		depth int //期望的调用堆栈层数(0表示无调用堆栈)
+	}{
+		{"default info", Option{}, LEVEL_INFO, 0},
+		{"default error", Option{}, LEVEL_ERROR, -1},
+		{"trace unset", Option{StackLevel: LEVEL_TRACE}, LEVEL_WARN, 0},
+		{"trace set", Option{StackLevel: LEVEL_TRACE, StackLevelSet: true}, LEVEL_TRACE, -1},
+		{"warn", Option{StackLevel: LEVEL_WARN, StackDepth: 2}, LEVEL_WARN, 2},
+		{"warn debug", Option{StackLevel: LEVEL_WARN, StackDepth: 2}, LEVEL_DEBUG, 0},
+		{"disabled", Option{StackLevel: LEVEL_TRACE, StackLevelSet: true, StackDepth: -1}, LEVEL_ERROR, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.opt.LogLevel = LEVEL_TRACE
+			l, s := newMemoryLogger(t, c.opt)
+			defer l.Close()
+			l.output(c.level, fmtArgs, "hello")
+			records := s.load()
+			if len(records) != 1 {
+				t.Fatalf("got %d records", len(records))
+			}
+			n := len(records[0].Stack)
+			switch {
+			case c.depth == 0 && n != 0:
+				t.Fatalf("unexpected stack %v", records[0].Stack)
+			case c.depth < 0 && n == 0:
+				t.Fatalf("stack not captured")
+			case c.depth > 0 && n != c.depth:
+				t.Fatalf("got %d frames, want %d: %v", n, c.depth, records[0].Stack)
+			}
+		})
+	}
+}
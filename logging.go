@@ -3,18 +3,11 @@ package log
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/fatih/color"
 	"github.com/mattn/go-colorable"
-	"log"
 	"os"
-	"path"
-	"path/filepath"
 	"reflect"
-	"runtime"
-	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -25,10 +18,27 @@ var LevelName = []string{"[TRACE]", "[DEBUG]", "[INFO]", "[WARN]", "[ERROR]", "[
 const (
 	DefaultLogSize    = 1024 //MB
 	DefaultMaxBackups = 31
+	DefaultStackDepth = 10 //调用堆栈层数
 )
 
+// 环境变量(仅作用于默认日志对象的默认选项, Open时显式传入的Option优先)
 const (
-	ENV_LOG_LEVEL = "LOG_LEVEL"
+	ENV_LOG_LEVEL          = "LOG_LEVEL"          //日志级别(trace/debug/info/warn/error/fatal或0~5)
+	ENV_LOG_FILE           = "LOG_FILE"           //日志文件路径
+	ENV_LOG_FILE_SIZE      = "LOG_FILE_SIZE"      //文件日志分割大小(MB)
+	ENV_LOG_MAX_BACKUPS    = "LOG_MAX_BACKUPS"    //文件最大分割数
+	ENV_LOG_MAX_AGE        = "LOG_MAX_AGE"        //备份文件最大保留天数
+	ENV_LOG_MAX_TOTAL_SIZE = "LOG_MAX_TOTAL_SIZE" //备份文件最大总大小(MB)
+	ENV_LOG_CONSOLE        = "LOG_CONSOLE"        //开启/关闭终端屏幕输出(on/off)
+	ENV_LOG_SHOW_PROCESS   = "LOG_SHOW_PROCESS"   //显示进程ID(true/false)
+	ENV_LOG_SHOW_ROUTINE   = "LOG_SHOW_ROUTINE"   //显示协程ID(true/false)
+	ENV_LOG_SHOW_CALLER    = "LOG_SHOW_CALLER"    //显示调用者信息(true/false)
+	ENV_LOG_FORMAT         = "LOG_FORMAT"         //文件日志输出格式(text/json/logfmt)
+	ENV_LOG_CONSOLE_FORMAT = "LOG_CONSOLE_FORMAT" //终端屏幕输出格式(text/json/logfmt)
+	ENV_LOG_ROTATE         = "LOG_ROTATE"         //按时间分割日志文件(hourly/daily/midnight或时长如30m)
+	ENV_LOG_COMPRESS       = "LOG_COMPRESS"       //备份文件压缩算法(gzip/zstd)
+	ENV_LOG_ASYNC          = "LOG_ASYNC"          //异步输出(true/false)
+	ENV_LOG_CALLER_FORMAT  = "LOG_CALLER_FORMAT"  //调用者信息格式(short/package/module)
 )
 
 const (
@@ -42,35 +52,78 @@ const (
 	LEVEL_JSON  = 7
 )
 
-type logInfo struct {
-	locker  sync.RWMutex
-	logFile *os.File    //日志文件对象
-	logger  *log.Logger //日志输出对象
-}
+// 日志输出格式
+const (
+	FormatText   = ""       //文本格式(默认)
+	FormatJSON   = "json"   //JSON Lines格式(每行一个JSON对象)
+	FormatLogfmt = "logfmt" //logfmt格式(key=value)
+)
+
+// 调用者信息格式
+const (
+	CallerShort   = ""        //文件名和函数名(默认), 如: handler.go:10 Method()
+	CallerPackage = "package" //文件名和带包名的函数名, 如: handler.go:10 api.(*Server).Method()
+	CallerModule  = "module"  //相对模块根目录的文件路径和带包名的函数名, 如: internal/api/handler.go:10 api.(*Server).Method()
+)
+
+// 按时间分割日志文件周期
+const (
+	RotateHourly = time.Hour      //每小时分割
+	RotateDaily  = 24 * time.Hour //每天分割
+)
 
 type Option struct {
-	LogLevel     int    //文件日志输出级别
-	FileSize     int    //文件日志分割大小(MB)
-	MaxBackups   int    //文件最大分割数
-	CloseConsole bool   //开启/关闭终端屏幕输出
-	ShowProcess  bool   //显示进程ID
-	ShowRoutine  bool   //显示协程ID
-	ShowCaller   bool   //显示调用者信息
-	filePath     string //文件日志路径
+	LogLevel         int           //文件日志输出级别
+	FileSize         int           //文件日志分割大小(MB)
+	MaxBackups       int           //文件最大分割数
+	MaxAge           int           //备份文件最大保留天数(0表示不限制)
+	MaxTotalSize     int           //备份文件最大总大小(MB, 0表示不限制)
+	CloseConsole     bool          //开启/关闭终端屏幕输出
+	ShowProcess      bool          //显示进程ID
+	ShowRoutine      bool          //显示协程ID
+	ShowCaller       bool          //显示调用者信息
+	StackLevel       int           //输出调用堆栈的最低日志级别(未设置时为LEVEL_ERROR)
+	StackLevelSet    bool          //StackLevel已设置(StackLevel为0时区分LEVEL_TRACE与未设置)
+	StackDepth       int           //调用堆栈最大层数(0表示DefaultStackDepth, 负数表示不输出调用堆栈)
+	StackMultiline   bool          //调用堆栈每层单独一行输出(文本格式)
+	StackAllOnFatal  bool          //FATAL级别输出全部协程的调用堆栈
+	FatalExit        bool          //FATAL级别日志输出后写出全部日志、执行退出钩子并退出进程(os.Exit(1))
+	PanicLog         bool          //Panic/Panicw先输出PANIC级别日志和调用堆栈再panic
+	RecentSize       int           //在内存中保留最近的日志记录数量(含低于日志级别的日志, ERROR及以上级别或DumpRecent时输出, 0表示不保留)
+	ScopeSize        int           //范围日志对象(Scope)最多缓存的日志数量(默认DefaultScopeSize)
+	CallerFormat     string        //调用者信息格式(CallerShort/CallerPackage/CallerModule)
+	Format           string        //文件日志输出格式(FormatText/FormatJSON/FormatLogfmt)
+	ConsoleFormat    string        //终端屏幕输出格式(FormatText/FormatJSON/FormatLogfmt)
+	RotateEvery      time.Duration //按时间分割日志文件(RotateHourly/RotateDaily或自定义时长, 0表示不按时间分割)
+	RotateAtMidnight bool          //每天本地时间0点分割日志文件
+	Compress         string        //备份文件压缩算法(CompressGzip/CompressZstd, 空表示不压缩)
+	Async            bool          //异步输出(有界队列+单个写入协程, 通过Flush等待写出)
+	AsyncQueueSize   int           //异步输出队列大小(默认DefaultAsyncQueueSize)
+	AsyncOverflow    int           //异步输出队列满时的处理策略(OverflowBlock/OverflowDropNewest/OverflowDropOldest/OverflowDropBelow)
+	AsyncDropLevel   int           //OverflowDropBelow策略下丢弃低于此级别的日志
+	filePath         string        //文件日志路径
 }
 
 // 全局变量
 var (
-	loginf logInfo //日志信息对象
-	option = Option{
+	defaultLogger = newLogger(Option{
 		LogLevel:   LEVEL_INFO,
 		FileSize:   DefaultLogSize,
 		MaxBackups: DefaultMaxBackups,
 		ShowCaller: true,
-	} //日志参数选项
+	}) //默认日志对象(包级别函数均输出到此对象)
 )
 
 func init() {
+	//从环境变量加载默认日志对象配置, 设置了LOG_FILE时自动打开日志文件
+	opt := defaultLogger.option
+	strPath := loadEnvOption(&opt)
+	defaultLogger.setOption(opt)
+	if strPath != "" {
+		_ = defaultLogger.Open(strPath)
+	} else {
+		defaultLogger.applyAsync()
+	}
 }
 
 func EnableStats(enable bool) {
@@ -78,192 +131,107 @@ func EnableStats(enable bool) {
 }
 
 func ShowProcess() {
-	option.ShowProcess = true
+	defaultLogger.ShowProcess()
 }
 
 func ShowRoutine() {
-	option.ShowRoutine = true
+	defaultLogger.ShowRoutine()
 }
 
 func DisableCaller() {
-	option.ShowCaller = false
+	defaultLogger.DisableCaller()
 }
 
+// 打开日志文件(filePath为空时使用环境变量LOG_FILE)
+// 未传入Option时使用当前选项(启动时已从环境变量加载), 传入的Option完全替换当前选项, 不再读取环境变量
 func Open(filePath string, opts ...Option) error {
-	err := loginf.openWithOptions(filePath, opts...)
-	if err != nil {
-		return Error("%s", err)
+	if filePath == "" {
+		filePath, _ = lookupEnv(ENV_LOG_FILE)
 	}
-	go backupLogFile()
-	return nil
+	return defaultLogger.Open(filePath, opts...)
 }
 
 // 关闭日志
 func Close() {
-	err := loginf.closeFile()
-	if err != nil {
-		Error("%s", err)
-		return
-	}
+	defaultLogger.Close()
 }
 
-// 设置日志文件分割大小（MB)
-func SetFileSize(size int) {
-	option.FileSize = size
+// 打开分级日志文件: level及以上级别的日志同时输出到该文件, 如: OpenLevelFile(LEVEL_ERROR, "logs/error.log")
+func OpenLevelFile(level int, filePath string, opts ...Option) error {
+	return defaultLogger.OpenLevelFile(level, filePath, opts...)
 }
 
-// 设置日志级别(字符串型: trace/debug/info/warn/error/fatal 数值型: 0=TRACE 1 =DEBUG 2=INFO 3=WARN 4=ERROR 5=FATAL)
-func SetLevel(level interface{}) {
-
-	var nLevel int
-	switch level.(type) {
-	case string:
-		strLevel := strings.ToLower(level.(string))
-		switch strLevel {
-		case "trace":
-			nLevel = LEVEL_TRACE
-		case "debug":
-			nLevel = LEVEL_DEBUG
-		case "info":
-			nLevel = LEVEL_INFO
-		case "warn", "warning":
-			nLevel = LEVEL_WARN
-		case "error":
-			nLevel = LEVEL_ERROR
-		case "fatal":
-			nLevel = LEVEL_FATAL
-		}
-	case int8, int16, int, int32, int64, uint8, uint16, uint, uint32, uint64:
-		nLevel, _ = strconv.Atoi(fmt.Sprintf("%v", level))
-	default:
-		nLevel = LEVEL_INFO
-	}
-	option.LogLevel = nLevel
+// 重新打开日志文件(配合外部logrotate使用)
+func Reopen() error {
+	return defaultLogger.Reopen()
 }
 
-// 设置关闭/开启屏幕输出
-func CloseConsole(ok bool) {
-	option.CloseConsole = ok
+// 收到指定信号时重新打开日志文件, 如: ReopenOnSignal(syscall.SIGHUP)
+// logrotate配置: postrotate kill -HUP <pid>
+func ReopenOnSignal(sigs ...os.Signal) {
+	defaultLogger.ReopenOnSignal(sigs...)
 }
 
-// 设置最大备份数量
-func SetMaxBackup(nMaxBackups int) {
-	option.MaxBackups = nMaxBackups
+// 设置日志文件分割大小（MB)
+func SetFileSize(size int) {
+	defaultLogger.SetFileSize(size)
 }
 
-// 定期清理日志，仅保留MaxBackups个数的日志
-func backupLogFile() {
-	for {
-		_ = loginf.renameFile()
-		_ = loginf.cleanBackupLog()
-		time.Sleep(30 * time.Second)
-	}
+// 创建默认日志对象的子对象, 获取调用位置时额外跳过n层调用(用于封装日志函数的辅助函数)
+func AddCallerSkip(n int) *Logger {
+	return defaultLogger.AddCallerSkip(n)
 }
 
-func (m *logInfo) Println(args ...interface{}) {
-	if loginf.logger == nil {
-		return
-	}
-	m.locker.Lock()
-	defer m.locker.Unlock()
-	loginf.logger.Println(args...)
+// 日志级别是否开启(可在输出开销较大的日志前判断)
+func IsEnabled(level int) bool {
+	return defaultLogger.enabled(level)
 }
 
-func (m *logInfo) openWithOptions(filePath string, opts ...Option) (err error) {
-	if filePath == "" {
-		return Error("log file path is required")
-	}
-	if len(opts) > 0 {
-		option = opts[0]
-	}
-	option.filePath = filePath
-	if option.FileSize == 0 {
-		option.FileSize = DefaultLogSize
-	}
-	if option.MaxBackups == 0 {
-		option.MaxBackups = DefaultMaxBackups
-	}
-	return m.createFile() //创建文件
+// 设置日志级别(字符串型: trace/debug/info/warn/error/fatal 数值型: 0=TRACE 1 =DEBUG 2=INFO 3=WARN 4=ERROR 5=FATAL)
+func SetLevel(level interface{}) {
+	defaultLogger.SetLevel(level)
 }
 
-// 关闭日志文件
-func (m *logInfo) closeFile() error {
-	m.locker.Lock()
-	defer m.locker.Unlock()
-	if loginf.logFile == nil {
-		return nil
-	}
-	_ = loginf.logFile.Close()
-	loginf.logFile = nil
-	return nil
+// 设置关闭/开启屏幕输出
+func CloseConsole(ok bool) {
+	defaultLogger.CloseConsole(ok)
 }
 
-// 清理已过期备份
-func (m *logInfo) cleanBackupLog() error {
-	var files []os.FileInfo
-	strPath := option.filePath
-	if strPath == "" {
-		return nil
-	}
-	dir, filename := filepath.Split(strPath)
-	if dir == "" {
-		dir = "."
-	}
-	match := filename + "."
-	filepath.Walk(dir,
-		func(path string, info os.FileInfo, err error) error {
-			if info.IsDir() {
-				return nil
-			}
-			if strings.Index(info.Name(), match) != -1 {
-				files = append(files, info)
-			}
-			return nil
-		})
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].ModTime().Unix() > files[j].ModTime().Unix()
-	})
-	count := len(files)
-	if count > option.MaxBackups && option.MaxBackups != 0 {
-		for i := option.MaxBackups - 1; i < count; i++ {
-			strFilePath := filepath.Join(dir, files[i].Name())
-			_ = os.Remove(strFilePath)
-		}
-	}
-	return nil
+// 设置最大备份数量
+func SetMaxBackup(nMaxBackups int) {
+	defaultLogger.SetMaxBackup(nMaxBackups)
 }
 
-// 关闭日志文件
-func (m *logInfo) renameFile() (err error) {
-	if loginf.logFile == nil {
-		return nil
-	}
-	fi, err := os.Stat(option.filePath)
-	if err != nil {
-		return err
+// 解析日志级别(字符串型或数值型)
+func parseLevel(level interface{}) (nLevel int) {
+	switch level.(type) {
+	case string:
+		nLevel, _ = levelFromString(level.(string))
+	case int8, int16, int, int32, int64, uint8, uint16, uint, uint32, uint64:
+		nLevel, _ = strconv.Atoi(fmt.Sprintf("%v", level))
+	default:
+		nLevel = LEVEL_INFO
 	}
-	fs := fi.Size()
-	renameSize := option.FileSize * 1024 * 1024
-
-	m.locker.Lock()
-	defer m.locker.Unlock()
+	return
+}
 
-	if fs > int64(renameSize) {
-		_ = loginf.logFile.Close()
-		datetime := time.Now().Format("20060102150405")
-		var strPath string
-		strPath = fmt.Sprintf("%v.%v", option.filePath, datetime) //日志文件有后缀(日志备份文件名格式不能随意改动)
-		err = os.Rename(option.filePath, strPath)                 //将文件备份
-		if err != nil {
-			return err
-		}
-		loginf.logFile, err = os.OpenFile(option.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-		if err != nil {
-			return err
-		}
-		loginf.logger = log.New(loginf.logFile, "", log.Lmicroseconds|log.LstdFlags)
+// 解析字符串型日志级别
+func levelFromString(strLevel string) (nLevel int, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(strLevel)) {
+	case "trace":
+		return LEVEL_TRACE, true
+	case "debug":
+		return LEVEL_DEBUG, true
+	case "info":
+		return LEVEL_INFO, true
+	case "warn", "warning":
+		return LEVEL_WARN, true
+	case "error":
+		return LEVEL_ERROR, true
+	case "fatal":
+		return LEVEL_FATAL, true
 	}
-	return nil
+	return LEVEL_TRACE, false
 }
 
 // getDirFromPath从给定的完整文件路径中提取出目录部分
@@ -299,34 +267,6 @@ func createDirIfNotExist(dir string) error {
 	return err
 }
 
-// 创建日志文件
-func (m *logInfo) createFile() error {
-	var err error
-	//检查目录路径是否存在，不存在自动创建目录和子目录
-	dir := getDirFromPath(option.filePath)
-	err = createDirIfNotExist(dir)
-	if err != nil {
-		return err
-	}
-	m.locker.Lock()
-	defer m.locker.Unlock()
-	loginf.logFile, err = os.OpenFile(option.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-	if err != nil {
-		return Error("Open log file ", option.filePath, " failed ", err)
-	}
-	loginf.logger = log.New(loginf.logFile, "", log.Lmicroseconds|log.LstdFlags)
-	return nil
-}
-
-// 截取函数名称
-func getFuncName(pc uintptr) (name string) {
-
-	n := runtime.FuncForPC(pc).Name()
-	ns := strings.Split(n, ".")
-	name = ns[len(ns)-1]
-	return
-}
-
 // 通过级别名称获取索引
 func getLevel(name string) (idx int) {
 
@@ -352,112 +292,27 @@ func getLevel(name string) (idx int) {
 	return
 }
 
-func getCaller(skip int) (strFile, strFunc string, nLineNo int) {
-	pc, file, line, ok := runtime.Caller(skip)
-	if ok {
-		strFile = path.Base(file)
-		nLineNo = line
-		strFunc = getFuncName(pc)
+// 调用堆栈文本(单行: 附加在日志内容之后, 多行: 每层调用单独一行)
+func fmtStack(stack []string, multiline bool) string {
+	if len(stack) == 0 {
+		return ""
 	}
-	return
-}
-
-func getStack(skip, n int) string {
 	var strStack string
-	strStack += "\t###CALLSTACK### { "
-	for i := 0; i < n; i++ {
-		pc, file, line, ok := runtime.Caller(skip + i)
-		if ok {
-			strFile := path.Base(file)
-			nLineNo := line
-			strFunc := getFuncName(pc)
-			strStack += fmt.Sprintf("%s:%d %s(); ", strFile, nLineNo, strFunc)
+	if multiline {
+		strStack += "\n\t###CALLSTACK###"
+		for _, s := range stack {
+			strStack += "\n\t\t" + s
 		}
+		return strStack
+	}
+	strStack += "\t###CALLSTACK### { "
+	for _, s := range stack {
+		strStack += s + "; "
 	}
 	strStack += "}"
-	strStack = color.CyanString(strStack)
 	return strStack
 }
 
-// 内部格式化输出函数
-func output(level int, formatter interface{}, args ...interface{}) (strFile, strFunc string, nLineNo int) {
-	var inf, code string
-	var colorTimeName string
-
-	strTimeFmt := fmt.Sprintf("%v", time.Now().Format("2006-01-02 15:04:05.000000"))
-	strRoutine := fmt.Sprintf("{%v}", getRoutineId())
-	strPID := fmt.Sprintf("PID:%d", os.Getpid())
-	Name := LevelName[level]
-	if !option.ShowProcess {
-		strPID = ""
-	}
-	if !option.ShowRoutine {
-		strRoutine = ""
-	}
-
-	switch level {
-	case LEVEL_TRACE:
-		colorTimeName = fmt.Sprintf("\033[38m%v %s %s", strTimeFmt, strPID, Name)
-	case LEVEL_DEBUG:
-		colorTimeName = fmt.Sprintf("\033[34m%v %s %s", strTimeFmt, strPID, Name)
-	case LEVEL_INFO:
-		colorTimeName = fmt.Sprintf("\033[32m%v %s %s", strTimeFmt, strPID, Name)
-	case LEVEL_WARN:
-		colorTimeName = fmt.Sprintf("\033[33m%v %s %s", strTimeFmt, strPID, Name)
-	case LEVEL_ERROR:
-		colorTimeName = fmt.Sprintf("\033[31m%v %s %s", strTimeFmt, strPID, Name)
-	case LEVEL_FATAL:
-		colorTimeName = fmt.Sprintf("\033[35m%v %s %s", strTimeFmt, strPID, Name)
-	case LEVEL_PANIC:
-		colorTimeName = fmt.Sprintf("\033[35m%v %s %s", strTimeFmt, strPID, Name)
-	default:
-		colorTimeName = fmt.Sprintf("\033[34m%v %s %s", strTimeFmt, strPID, Name)
-	}
-	var fmtstr string
-	switch formatter.(type) {
-	case string:
-		fmtstr = formatter.(string)
-		if formatter != "" {
-			inf = fmt.Sprintf(fmtstr, args...)
-		} else {
-			inf = fmt.Sprint(args...)
-		}
-	case error:
-		err := formatter.(error)
-		fmtstr = fmt.Sprintf("%v", err.Error())
-	}
-
-	strFile, strFunc, nLineNo = getCaller(3)
-	code = "<" + strFile + ":" + strconv.Itoa(nLineNo) + " " + strFunc + "()" + ">"
-	if level < option.LogLevel {
-		return
-	}
-	if !option.ShowCaller {
-		code = ""
-	}
-	var outstr string
-
-	switch runtime.GOOS {
-	//case "windows": //Windows终端（无颜色）
-	//outstr = strTimeFmt + " " + Name + " " + strRoutine + " " + code + " " + inf
-	default: //Unix类终端支持颜色显示
-		outstr = "\033[1m" + colorTimeName + " " + strRoutine + " " + code + "\033[0m " + inf
-	}
-
-	if level >= LEVEL_ERROR && level != LEVEL_JSON {
-		outstr += getStack(3, 10)
-	}
-	//打印到终端屏幕
-	if !option.CloseConsole {
-		_, _ = fmt.Fprintln(colorStdout /*os.Stdout*/, outstr)
-	}
-
-	//输出到文件（如果Open函数传入了正确的文件路径）
-	loginf.Println(Name + " " + strRoutine + " " + code + " " + inf)
-
-	return
-}
-
 func fmtString(args ...interface{}) (strOut string) {
 	if len(args) > 0 {
 		switch args[0].(type) {
@@ -477,6 +332,14 @@ func fmtString(args ...interface{}) (strOut string) {
 	return
 }
 
+// 日志参数的格式化方式(作为output的formatter, 日志参数在输出时才格式化)
+type argsFormat int
+
+const (
+	fmtArgs  argsFormat = iota //按fmtString格式化
+	fmtArgsW                   //按fmtStringW格式化
+)
+
 func fmtStringW(args ...interface{}) (strOut string) {
 	var strArgs []string
 	for _, v := range args {
@@ -492,150 +355,201 @@ func Printf(args ...interface{}) {
 
 // 输出调试级别信息
 func Trace(args ...interface{}) {
-	output(LEVEL_TRACE, fmtString(args...))
+	if !defaultLogger.handled(LEVEL_TRACE) {
+		return
+	}
+	defaultLogger.output(LEVEL_TRACE, fmtArgs, args...)
 }
 
 // 输出调试级别信息
 func Debug(args ...interface{}) {
-	output(LEVEL_DEBUG, fmtString(args...))
+	if !defaultLogger.handled(LEVEL_DEBUG) {
+		return
+	}
+	defaultLogger.output(LEVEL_DEBUG, fmtArgs, args...)
 }
 
 // 输出运行级别信息
 func Info(args ...interface{}) {
-	output(LEVEL_INFO, fmtString(args...))
+	if !defaultLogger.handled(LEVEL_INFO) {
+		return
+	}
+	defaultLogger.output(LEVEL_INFO, fmtArgs, args...)
 }
 
 // 输出警告级别信息
 func Warn(args ...interface{}) {
-	output(LEVEL_WARN, fmtString(args...))
+	if !defaultLogger.handled(LEVEL_WARN) {
+		return
+	}
+	defaultLogger.output(LEVEL_WARN, fmtArgs, args...)
 }
 
 // 输出警告级别信息
 func Warning(args ...interface{}) {
-	output(LEVEL_WARN, fmtString(args...))
+	if !defaultLogger.handled(LEVEL_WARN) {
+		return
+	}
+	defaultLogger.output(LEVEL_WARN, fmtArgs, args...)
 }
 
 // 输出错误级别信息
 func Error(args ...interface{}) error {
-	err := fmt.Errorf(fmtString(args...))
-	stic.error(output(LEVEL_ERROR, err.Error()))
+	err := defaultLogger.newError(LEVEL_ERROR, argsToError(args...))
+	stic.error(defaultLogger.output(LEVEL_ERROR, loggedError{err}))
 	return err
 }
 
 // 输出危险级别信息
 func Fatal(args ...interface{}) error {
-	err := fmt.Errorf(fmtString(args...))
-	stic.error(output(LEVEL_FATAL, err.Error()))
+	err := defaultLogger.newError(LEVEL_FATAL, argsToError(args...))
+	stic.error(defaultLogger.output(LEVEL_FATAL, loggedError{err}))
 	return err
 }
 
 // panic
 func Panic(args ...interface{}) {
+	if defaultLogger.option.PanicLog {
+		defaultLogger.output(LEVEL_PANIC, fmtArgs, args...)
+		defaultLogger.Flush()
+	}
 	panic(fmt.Sprintf(fmtString(args...)))
 }
 
 // 输出调试级别信息
 func Tracef(formatter interface{}, args ...interface{}) {
-	output(LEVEL_TRACE, formatter, args...)
+	if !defaultLogger.handled(LEVEL_TRACE) {
+		return
+	}
+	defaultLogger.output(LEVEL_TRACE, formatter, args...)
 }
 
 // 输出调试级别信息
 func Debugf(formatter interface{}, args ...interface{}) {
-	output(LEVEL_DEBUG, formatter, args...)
+	if !defaultLogger.handled(LEVEL_DEBUG) {
+		return
+	}
+	defaultLogger.output(LEVEL_DEBUG, formatter, args...)
 }
 
 // 输出运行级别信息
 func Infof(formatter interface{}, args ...interface{}) {
-	output(LEVEL_INFO, formatter, args...)
+	if !defaultLogger.handled(LEVEL_INFO) {
+		return
+	}
+	defaultLogger.output(LEVEL_INFO, formatter, args...)
 }
 
 // 输出警告级别信息
 func Warnf(formatter interface{}, args ...interface{}) {
-	output(LEVEL_WARN, formatter, args...)
+	if !defaultLogger.handled(LEVEL_WARN) {
+		return
+	}
+	defaultLogger.output(LEVEL_WARN, formatter, args...)
 }
 
 // 输出警告级别信息
 func Warningf(formatter interface{}, args ...interface{}) {
-	output(LEVEL_WARN, formatter, args...)
+	if !defaultLogger.handled(LEVEL_WARN) {
+		return
+	}
+	defaultLogger.output(LEVEL_WARN, formatter, args...)
 }
 
 // 输出错误级别信息
 func Errorf(formatter interface{}, args ...interface{}) error {
-	var err error
-	err = formatterToError(formatter, args...)
-	if err == nil {
+	cause := formatterToError(formatter, args...)
+	if cause == nil {
 		return nil
 	}
-	stic.error(output(LEVEL_ERROR, err.Error()))
+	err := defaultLogger.newError(LEVEL_ERROR, cause)
+	stic.error(defaultLogger.output(LEVEL_ERROR, loggedError{err}))
 	return err
 }
 
 // 输出危险级别信息
 func Fatalf(formatter interface{}, args ...interface{}) error {
-	var err error
-	err = formatterToError(formatter, args...)
-	if err == nil {
+	cause := formatterToError(formatter, args...)
+	if cause == nil {
 		return nil
 	}
-	stic.error(output(LEVEL_FATAL, err.Error()))
+	err := defaultLogger.newError(LEVEL_FATAL, cause)
+	stic.error(defaultLogger.output(LEVEL_FATAL, loggedError{err}))
 	return err
 }
 
+// 将格式化参数转换为错误(保留%w包装的错误)
 func formatterToError(formatter interface{}, args ...interface{}) (err error) {
-	var fmtstr string
 	switch formatter.(type) {
 	case string:
-		fmtstr = formatter.(string)
+		fmtstr := formatter.(string)
 		if fmtstr != "" {
-			fmtstr = fmt.Sprintf(fmtstr, args...)
+			err = fmt.Errorf(fmtstr, args...)
 		} else {
-			fmtstr = fmt.Sprint(args...)
+			err = argsToError(args...)
 		}
-		err = fmt.Errorf(fmtstr)
 	case error:
 		err = formatter.(error)
-		fmtstr = fmt.Sprintf("%v", err.Error())
 	}
 	return err
 }
 
 // 输出Trace级别信息
 func Tracew(args ...interface{}) {
-	output(LEVEL_DEBUG, fmtStringW(args...))
+	if !defaultLogger.handled(LEVEL_DEBUG) {
+		return
+	}
+	defaultLogger.output(LEVEL_DEBUG, fmtArgsW, args...)
 }
 
 // 输出调试级别信息
 func Debugw(args ...interface{}) {
-	output(LEVEL_DEBUG, fmtStringW(args...))
+	if !defaultLogger.handled(LEVEL_DEBUG) {
+		return
+	}
+	defaultLogger.output(LEVEL_DEBUG, fmtArgsW, args...)
 }
 
 // 输出运行级别信息
 func Infow(args ...interface{}) {
-	output(LEVEL_INFO, fmtStringW(args...))
+	if !defaultLogger.handled(LEVEL_INFO) {
+		return
+	}
+	defaultLogger.output(LEVEL_INFO, fmtArgsW, args...)
 }
 
 // 输出警告级别信息
 func Warnw(args ...interface{}) {
-	output(LEVEL_WARN, fmtStringW(args...))
+	if !defaultLogger.handled(LEVEL_WARN) {
+		return
+	}
+	defaultLogger.output(LEVEL_WARN, fmtArgsW, args...)
 }
 
 // 输出警告级别信息
 func Warningw(args ...interface{}) {
-	output(LEVEL_WARN, fmtStringW(args...))
+	if !defaultLogger.handled(LEVEL_WARN) {
+		return
+	}
+	defaultLogger.output(LEVEL_WARN, fmtArgsW, args...)
 }
 
 // 输出错误级别信息
 func Errorw(args ...interface{}) {
-	stic.error(output(LEVEL_ERROR, fmtStringW(args...)))
+	stic.error(defaultLogger.output(LEVEL_ERROR, fmtArgsW, args...))
 }
 
 // 输出危险级别信息
 func Fatalw(args ...interface{}) {
-	stic.error(output(LEVEL_FATAL, fmtStringW(args...)))
+	stic.error(defaultLogger.output(LEVEL_FATAL, fmtArgsW, args...))
 }
 
 // panic
 func Panicw(args ...interface{}) {
+	if defaultLogger.option.PanicLog {
+		defaultLogger.output(LEVEL_PANIC, fmtArgsW, args...)
+		defaultLogger.Flush()
+	}
 	panic(fmt.Sprintf(fmtStringW(args...)))
 }
 
@@ -644,16 +558,24 @@ func Null(fmtstr string, args ...interface{}) {
 }
 
 func Truncate(level, size int, fmtstr string, args ...interface{}) {
+	if !defaultLogger.handled(level) {
+		return
+	}
+	defaultLogger.output(level, fmtTruncate(size, fmtstr, args...))
+}
+
+// 截断超过size长度的日志内容
+func fmtTruncate(size int, fmtstr string, args ...interface{}) string {
 	strOutput := fmt.Sprintf(fmtstr, args...)
 	if len(strOutput) > size {
 		strOutput = strOutput[:size] + "..."
 	}
-	output(level, strOutput)
+	return strOutput
 }
 
 // 进入方法（统计）
 func Enter(args ...interface{}) {
-	output(LEVEL_INFO, "enter ", args...)
+	defaultLogger.output(LEVEL_INFO, "enter ", args...)
 	stic.enter(getCaller(2))
 }
 
@@ -663,13 +585,18 @@ func Leave() (h, m, s int, ms float32) {
 
 	if nSpendTime, ok := stic.leave(getCaller(2)); ok {
 		h, m, s, ms = getSpendTime(nSpendTime)
-		output(LEVEL_INFO, "leave (%vh %vm %vs %.3fms)", h, m, s, ms)
+		defaultLogger.output(LEVEL_INFO, "leave (%vh %vm %vs %.3fms)", h, m, s, ms)
 	}
 	return
 }
 
 // 打印结构体JSON
 func Json(args ...interface{}) {
+	defaultLogger.output(LEVEL_JSON, fmtJson(args...))
+}
+
+// 格式化结构体JSON
+func fmtJson(args ...interface{}) string {
 
 	var strOutput string
 
@@ -679,7 +606,7 @@ func Json(args ...interface{}) {
 		strOutput += "\n...................................................\n" + string(data)
 	}
 
-	output(LEVEL_JSON, strOutput+"\n...................................................\n")
+	return strOutput + "\n...................................................\n"
 }
 
 func JsonDebugString(v interface{}) string {
@@ -694,6 +621,16 @@ func Report(args ...interface{}) string {
 
 // 打印结构体
 func Struct(args ...interface{}) {
+	if !defaultLogger.handled(LEVEL_DEBUG) {
+		return
+	}
+	for _, strLog := range fmtStructs(args...) {
+		defaultLogger.output(LEVEL_DEBUG, strLog)
+	}
+}
+
+// 格式化结构体(每个参数对应一条日志)
+func fmtStructs(args ...interface{}) (logs []string) {
 
 	var strLog string
 	for i := range args {
@@ -717,8 +654,9 @@ func Struct(args ...interface{}) {
 			strLog += fmt.Sprintf("%v (%v) = <%+v> \n", typ.Name(), typ.Kind(), val.Interface())
 		}
 
-		output(LEVEL_DEBUG, strLog)
+		logs = append(logs, strLog)
 	}
+	return
 }
 
 // 将字段值存到其他类型的变量中
@@ -797,4 +735,3 @@ func fmtDeep(nDeep int) (s string) {
 	}
 	return
 }
-
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// 最近日志记录(飞行记录器): 在内存中保留最近RecentSize条日志(含低于日志级别的日志),
+// 输出ERROR及以上级别日志或调用DumpRecent时, 将其中未输出的日志写到日志输出
+type recorder struct {
+	locker sync.Mutex
+	items  []recentItem //环形缓冲区
+	next   int          //下一条记录的位置
+	count  int          //记录数量
+}
+
+// 最近日志记录项(记录时格式化日志内容, 输出时显示记录时的参数值)
+type recentItem struct {
+	written bool      //已输出到日志输出
+	time    time.Time //日志时间
+	level   int       //日志级别
+	pc      uintptr   //调用者PC
+	msg     string    //日志内容
+	fields  []Field   //结构化字段
+}
+
+func newRecorder(size int) *recorder {
+	return &recorder{
+		items: make([]recentItem, size),
+	}
+}
+
+func (rc *recorder) add(item recentItem) {
+	rc.locker.Lock()
+	defer rc.locker.Unlock()
+	rc.items[rc.next] = item
+	rc.next = (rc.next + 1) % len(rc.items)
+	if rc.count < len(rc.items) {
+		rc.count++
+	}
+}
+
+// 按时间顺序取出全部记录并清空
+func (rc *recorder) take() (items []recentItem) {
+	rc.locker.Lock()
+	defer rc.locker.Unlock()
+	items = make([]recentItem, 0, rc.count)
+	for i := rc.count; i > 0; i-- {
+		idx := (rc.next - i + len(rc.items)) % len(rc.items)
+		items = append(items, rc.items[idx])
+		rc.items[idx] = recentItem{}
+	}
+	rc.count = 0
+	return
+}
+
+// 输出默认日志对象最近日志记录中未输出的日志(低于日志级别的日志)
+func DumpRecent() {
+	defaultLogger.DumpRecent()
+}
+
+// 输出最近日志记录中未输出的日志(低于日志级别的日志), 每条日志附带recent=true字段
+func (l *Logger) DumpRecent() {
+	rc := l.loadRecent()
+	if rc == nil {
+		return
+	}
+	pid := os.Getpid()
+	for _, it := range rc.take() {
+		if it.written {
+			continue
+		}
+		r := &Record{
+			Time:   it.time,
+			Level:  it.level,
+			PID:    pid,
+			Msg:    it.msg,
+			Fields: append(append(make([]Field, 0, len(it.fields)+1), it.fields...), Field{Key: "recent", Value: true}),
+		}
+		if it.pc != 0 {
+			c := lookupCaller(it.pc)
+			r.File, r.Func = c.format(l.option.CallerFormat)
+			r.Line = c.line
+		}
+		l.emit(r)
+	}
+}
+
+// 保存低于日志级别的日志到最近日志记录(未开启时直接返回)
+func (l *Logger) recordRecent(level int, skip int, formatter interface{}, args []interface{}) {
+	rc := l.loadRecent()
+	if rc == nil {
+		return
+	}
+	rc.add(recentItem{
+		time:   time.Now(),
+		level:  level,
+		pc:     getCallerPC(skip + 1),
+		msg:    fmtMessage(formatter, args), //立即格式化: 参数之后被修改或被其他协程写入时不影响记录内容
+		fields: l.fields,
+	})
+}
+
+func (l *Logger) addRecent(item recentItem) {
+	if rc := l.loadRecent(); rc != nil {
+		rc.add(item)
+	}
+}
+
+func (l *Logger) loadRecent() *recorder {
+	rc, _ := l.recent.Load().(*recorder)
+	return rc
+}
+
+// 按选项开启、关闭最近日志记录或调整记录数量
+func (l *Logger) applyRecent() {
+	rc := l.loadRecent()
+	if l.option.RecentSize <= 0 {
+		if rc != nil {
+			l.recent.Store((*recorder)(nil))
+		}
+		return
+	}
+	if rc == nil || len(rc.items) != l.option.RecentSize {
+		l.recent.Store(newRecorder(l.option.RecentSize))
+	}
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 创建开启最近日志记录的日志对象, 返回已输出的日志内容
+func newRecentLogger(t *testing.T) (*Logger, func() []string) {
+	t.Helper()
+	l, s := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO, RecentSize: 10})
+	return l, s.messages
+}
+
+func TestRecentSnapshot(t *testing.T) {
+	l, output := newRecentLogger(t)
+	defer l.Close()
+	buf := []byte("before")
+	l.Debugf("value %s", buf)
+	copy(buf, "AFTER!")
+	l.Error("boom")
+	if got := output(); len(got) != 2 || got[0] != "value before" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRecentConcurrentMap(t *testing.T) {
+	l, output := newRecentLogger(t)
+	defer l.Close()
+	m := map[int]int{}
+	var locker sync.Mutex
+	for i := 0; i < 100; i++ {
+		locker.Lock()
+		l.Debugf("map %v", m)
+		locker.Unlock()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			locker.Lock()
+			m[i] = i //记录之后修改参数
+			locker.Unlock()
+		}
+	}()
+	l.DumpRecent() //在其他协程写入map时输出
+	<-done
+	if got := output(); len(got) != 10 || !strings.HasPrefix(got[0], "map ") {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+const DefaultScopeSize = 10000 //范围日志对象默认最多缓存的日志数量
+
+type scopeKey struct{}
+
+// 范围日志对象(Scope创建): 不低于日志级别的日志直接输出, 缓存一次请求或任务中低于日志级别的日志,
+// 输出ERROR及以上级别日志或调用Commit时决定是否输出
+type ScopedLogger struct {
+	*Logger
+	ctx context.Context
+}
+
+// 日志缓存
+type scopeBuffer struct {
+	locker  sync.Mutex
+	records []*Record //缓存的低于日志级别的日志
+	size    int       //最多缓存的日志数量(超出后丢弃)
+	failed  bool      //已输出ERROR及以上级别日志(缓存已输出, 提交前低于日志级别的日志直接输出)
+	done    bool      //已提交
+}
+
+// 创建范围日志对象(默认日志对象), 附带ctx中的日志字段, 调用Commit结束缓存
+func Scope(ctx context.Context) *ScopedLogger {
+	return defaultLogger.Scope(ctx)
+}
+
+// 创建范围日志对象, 附带ctx中的日志字段, 调用Commit结束缓存
+func (l *Logger) Scope(ctx context.Context) *ScopedLogger {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	size := l.option.ScopeSize
+	if size <= 0 {
+		size = DefaultScopeSize
+	}
+	sb := &scopeBuffer{size: size}
+	ctx = context.WithValue(ctx, scopeKey{}, sb)
+	c := l.WithContext(ctx)
+	return &ScopedLogger{
+		Logger: &Logger{
+			logInfo: c.logInfo,
+			fields:  c.fields,
+			skip:    c.skip,
+			scope:   sb,
+		},
+		ctx: ctx,
+	}
+}
+
+// 返回附带范围日志的context, 通过该context输出的日志(XxxCtx/FromContext)同样被缓存
+func (s *ScopedLogger) Context() context.Context {
+	return s.ctx
+}
+
+// 提交缓存的日志: err不为nil时按原有顺序输出缓存的低于日志级别的日志, 否则丢弃; 提交后的日志不再缓存
+// (未调用Commit时只丢失低于日志级别的日志)
+func (s *ScopedLogger) Commit(err error) {
+	s.flushScope(err != nil)
+}
+
+// 获取context中的范围日志缓存
+func scopeFromContext(ctx context.Context) *scopeBuffer {
+	if ctx == nil {
+		return nil
+	}
+	sb, _ := ctx.Value(scopeKey{}).(*scopeBuffer)
+	return sb
+}
+
+// 是否处理低于日志级别的日志(未提交且缓存未满或已输出ERROR及以上级别日志)
+func (sb *scopeBuffer) active() bool {
+	sb.locker.Lock()
+	defer sb.locker.Unlock()
+	return !sb.done && (sb.failed || len(sb.records) < sb.size)
+}
+
+// 处理日志记录(below表示低于日志级别), 返回需要先按顺序输出的缓存日志和r是否输出
+// ERROR及以上级别日志输出全部缓存的日志, 之后提交前低于日志级别的日志不再缓存直接输出
+func (sb *scopeBuffer) add(r *Record, below bool) (records []*Record, write bool) {
+	sb.locker.Lock()
+	defer sb.locker.Unlock()
+	switch {
+	case sb.done:
+		return nil, !below
+	case sb.failed:
+		return nil, true
+	case r.Level >= LEVEL_ERROR && r.Level != LEVEL_JSON:
+		sb.failed = true
+		records, sb.records = sb.records, nil
+		return records, true
+	case !below:
+		return nil, true
+	case len(sb.records) < sb.size:
+		sb.records = append(sb.records, r)
+	}
+	return nil, false
+}
+
+// 结束缓存并取出缓存的日志记录
+func (sb *scopeBuffer) take() (records []*Record) {
+	sb.locker.Lock()
+	defer sb.locker.Unlock()
+	records, sb.records = sb.records, nil
+	sb.done = true
+	return records
+}
+
+// 提交范围日志缓存(failed为true时按顺序输出缓存的日志, 否则丢弃)
+func (l *Logger) flushScope(failed bool) {
+	if l.scope == nil {
+		return
+	}
+	records := l.scope.take()
+	if !failed {
+		return
+	}
+	for _, r := range records {
+		l.emit(r)
+	}
+}
+
+// 日志是否需要处理或ctx中有未提交的范围日志
+func (l *Logger) handledCtx(ctx context.Context, level int) bool {
+	if l.handled(level) {
+		return true
+	}
+	sb := scopeFromContext(ctx)
+	return sb != nil && sb.active()
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 创建只输出到内存的日志对象, 返回已输出的日志内容
+func newScopeLogger(t *testing.T, size int) (*Logger, func() string) {
+	t.Helper()
+	l, s := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO, ScopeSize: size})
+	return l, func() string {
+		return strings.Join(s.messages(), ",")
+	}
+}
+
+func TestScope(t *testing.T) {
+	cases := []struct {
+		name   string
+		size   int
+		log    func(s *ScopedLogger)
+		err    error
+		before string //提交前已输出的日志(不低于日志级别的日志直接输出)
+		want   string //提交后输出的全部日志
+	}{
+		{"success", 10, func(s *ScopedLogger) {
+			s.Debug("d1")
+			s.Info("i1")
+			s.Debug("d2")
+		}, nil, "i1", "i1"},
+		{"commit error", 10, func(s *ScopedLogger) {
+			s.Debug("d1")
+			s.Info("i1")
+			s.Debug("d2")
+		}, errors.New("failed"), "i1", "i1,d1,d2"},
+		{"error record", 10, func(s *ScopedLogger) {
+			s.Debug("d1")
+			s.Info("i1")
+			s.Error("e1")
+			s.Debug("d2")
+		}, nil, "i1,d1,e1,d2", "i1,d1,e1,d2"},
+		{"overflow", 2, func(s *ScopedLogger) {
+			s.Debug("d1")
+			s.Info("i1")
+			s.Debug("d2")
+			s.Debug("d3")
+			s.Info("i2")
+		}, errors.New("failed"), "i1,i2", "i1,i2,d1,d2"},
+		{"overflow then error", 1, func(s *ScopedLogger) {
+			s.Debug("d1")
+			s.Debug("d2")
+			s.Error("e1")
+			s.Debug("d3")
+		}, nil, "d1,e1,d3", "d1,e1,d3"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, output := newScopeLogger(t, c.size)
+			defer l.Close()
+			s := l.Scope(context.Background())
+			c.log(s)
+			if got := output(); got != c.before {
+				t.Fatalf("before commit got %q, want %q", got, c.before)
+			}
+			s.Commit(c.err)
+			s.Debug("after")
+			if got := output(); got != c.want {
+				t.Fatalf("after commit got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestScopeContext(t *testing.T) {
+	l, output := newScopeLogger(t, 10)
+	defer l.Close()
+	s := l.Scope(context.Background())
+	ctx := s.Context()
+	l.DebugCtx(ctx, "d1")
+	s.Info("i1")
+	if got := output(); got != "i1" {
+		t.Fatalf("at-level record held back: %q", got)
+	}
+	l.ErrorCtx(ctx, "e1")
+	if got := output(); got != "i1,d1,e1" {
+		t.Fatalf("got %q", got)
+	}
+	s.Commit(nil)
+}
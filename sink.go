@@ -0,0 +1,175 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	SinkConsole = "console" //内置终端屏幕输出
+	SinkFile    = "file"    //内置日志文件输出
+)
+
+// 日志输出接口(Write不能修改或持有Record)
+type Sink interface {
+	Write(r *Record) error
+}
+
+// 可同步的日志输出(FATAL退出进程前调用Sync将缓冲的日志写入存储)
+type Syncer interface {
+	Sync() error
+}
+
+// 函数形式的日志输出
+type SinkFunc func(r *Record) error
+
+func (f SinkFunc) Write(r *Record) error {
+	return f(r)
+}
+
+type sinkEntry struct {
+	name  string
+	sink  Sink
+	level int //输出的最低级别(在日志对象级别之上再过滤)
+}
+
+// 日志输出列表(写时复制, 输出时无需加锁)
+type sinkList struct {
+	locker sync.Mutex
+	value  atomic.Value
+}
+
+func (s *sinkList) load() []sinkEntry {
+	sinks, _ := s.value.Load().([]sinkEntry)
+	return sinks
+}
+
+// 添加或替换同名日志输出
+func (s *sinkList) add(name string, sink Sink, level int) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	old := s.load()
+	sinks := make([]sinkEntry, 0, len(old)+1)
+	for _, e := range old {
+		if e.name != name {
+			sinks = append(sinks, e)
+		}
+	}
+	s.value.Store(append(sinks, sinkEntry{name: name, sink: sink, level: level}))
+}
+
+func (s *sinkList) remove(name string) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	old := s.load()
+	sinks := make([]sinkEntry, 0, len(old))
+	for _, e := range old {
+		if e.name != name {
+			sinks = append(sinks, e)
+		}
+	}
+	s.value.Store(sinks)
+}
+
+// 添加日志输出(默认日志对象), 同名输出将被替换, level为该输出的最低级别
+func AddSink(name string, sink Sink, level int) {
+	defaultLogger.AddSink(name, sink, level)
+}
+
+// 移除日志输出(默认日志对象)
+func RemoveSink(name string) {
+	defaultLogger.RemoveSink(name)
+}
+
+// 添加日志输出, 同名输出将被替换(内置输出: SinkConsole/SinkFile), level为该输出的最低级别
+func (l *Logger) AddSink(name string, sink Sink, level int) {
+	l.sinks.add(name, sink, level)
+}
+
+// 移除日志输出
+func (l *Logger) RemoveSink(name string) {
+	l.sinks.remove(name)
+}
+
+// 同步全部实现Syncer的日志输出
+func (l *Logger) syncSinks() {
+	for _, e := range l.sinks.load() {
+		if s, ok := e.sink.(Syncer); ok {
+			_ = s.Sync()
+		}
+	}
+}
+
+// 输出到io.Writer的日志输出(按encoder编码)
+type writerSink struct {
+	locker sync.Mutex
+	writer io.Writer
+	enc    Encoder
+}
+
+// 同步日志输出(w实现Syncer时, 如: *os.File)
+func (s *writerSink) Sync() error {
+	if w, ok := s.writer.(Syncer); ok {
+		s.locker.Lock()
+		defer s.locker.Unlock()
+		return w.Sync()
+	}
+	return nil
+}
+
+// 创建输出到io.Writer的日志输出
+func NewWriterSink(w io.Writer, enc Encoder) Sink {
+	return &writerSink{writer: w, enc: enc}
+}
+
+func (s *writerSink) Write(r *Record) error {
+	data := s.enc.Encode(r)
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	_, err := s.writer.Write(data)
+	return err
+}
+
+// 终端屏幕输出(内置sink)
+type consoleSink struct {
+	option *Option
+	enc    atomic.Value //编码器(encoderBox, 选项变化时重新创建)
+}
+
+// 原子保存的编码器(atomic.Value要求保存的类型一致)
+type encoderBox struct {
+	Encoder
+}
+
+func newConsoleSink(opt *Option) *consoleSink {
+	s := &consoleSink{option: opt}
+	s.updateEncoder()
+	return s
+}
+
+// 按当前选项重新创建编码器
+func (s *consoleSink) updateEncoder() {
+	var enc Encoder
+	switch s.option.ConsoleFormat {
+	case FormatText:
+		enc = &TextEncoder{
+			Color:          true,
+			ShowProcess:    s.option.ShowProcess,
+			ShowRoutine:    s.option.ShowRoutine,
+			ShowCaller:     s.option.ShowCaller,
+			StackMultiline: s.option.StackMultiline,
+		}
+	default:
+		enc = NewEncoder(s.option.ConsoleFormat, *s.option)
+	}
+	s.enc.Store(encoderBox{enc})
+}
+
+func (s *consoleSink) Write(r *Record) error {
+	if s.option.CloseConsole {
+		return nil
+	}
+	_, err := colorStdout.Write(s.enc.Load().(encoderBox).Encode(r))
+	return err
+}
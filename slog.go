@@ -0,0 +1,191 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// slog.Handler实现: 日志记录按本包的级别、颜色、调用者格式输出到终端屏幕和日志文件
+type slogHandler struct {
+	logger *Logger
+	groups []string //WithGroup分组(字段名前缀)
+	fields []Field  //WithAttrs字段
+}
+
+// 创建slog.Handler(logger为nil时使用默认日志对象)
+//
+//	slog.SetDefault(slog.New(log.NewSlogHandler(nil)))
+func NewSlogHandler(logger *Logger) slog.Handler {
+	if logger == nil {
+		logger = defaultLogger
+	}
+	return &slogHandler{logger: logger}
+}
+
+// slog级别转换为本包级别
+func slogLevel(level slog.Level) int {
+	switch {
+	case level < slog.LevelDebug:
+		return LEVEL_TRACE
+	case level < slog.LevelInfo:
+		return LEVEL_DEBUG
+	case level < slog.LevelWarn:
+		return LEVEL_INFO
+	case level < slog.LevelError:
+		return LEVEL_WARN
+	case level < slog.LevelError+4:
+		return LEVEL_ERROR
+	default:
+		return LEVEL_FATAL
+	}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.handledCtx(ctx, slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := slogLevel(r.Level)
+	if !h.logger.handledCtx(ctx, level) {
+		return nil
+	}
+	logger := h.logger.WithContext(ctx)
+	fields := make([]Field, 0, len(logger.fields)+len(h.fields)+r.NumAttrs())
+	fields = append(fields, logger.fields...)
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.groups, a)
+		return true
+	})
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if !logger.enabled(level) {
+		//低于日志级别的日志只保存到最近日志记录
+		pc := r.PC
+		if pc != 0 && isHelper(lookupCaller(pc).function) {
+			pc = getCallersFromPC(pc, 1)[0]
+		}
+		h.logger.addRecent(recentItem{
+			time:   now,
+			level:  level,
+			pc:     pc,
+			msg:    r.Message,
+			fields: fields,
+		})
+		return nil
+	}
+	var strFile, strFunc string
+	var nLineNo int
+	var stack []string
+	if r.PC != 0 {
+		pcs := []uintptr{r.PC}
+		if depth := h.logger.stackDepth(level); depth > 0 {
+			pcs = getCallersFromPC(r.PC, depth)
+			stack = fmtCallers(pcs, h.logger.option.CallerFormat)
+		} else if isHelper(lookupCaller(r.PC).function) {
+			pcs = getCallersFromPC(r.PC, 1)
+		}
+		c := lookupCaller(pcs[0])
+		strFile, strFunc = c.format(h.logger.option.CallerFormat)
+		nLineNo = c.line
+	}
+	rec := &Record{
+		Time:       now,
+		Level:      level,
+		File:       strFile,
+		Func:       strFunc,
+		Line:       nLineNo,
+		Routine:    getRoutineId(),
+		PID:        os.Getpid(),
+		Msg:        r.Message,
+		Fields:     fields,
+		Stack:      stack,
+		Goroutines: h.logger.goroutineStacks(level),
+	}
+	if level >= LEVEL_ERROR {
+		stic.error(strFile, strFunc, nLineNo)
+	}
+	logger.dispatch(rec)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.fields = make([]Field, 0, len(h.fields)+len(attrs))
+	h2.fields = append(h2.fields, h.fields...)
+	for _, a := range attrs {
+		h2.fields = appendAttr(h2.fields, h.groups, a)
+	}
+	return &h2
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = make([]string, 0, len(h.groups)+1)
+	h2.groups = append(h2.groups, h.groups...)
+	h2.groups = append(h2.groups, name)
+	return &h2
+}
+
+// slog.Attr转换为字段(分组名作为字段名前缀, 如: req.id)
+func appendAttr(fields []Field, groups []string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			fields = appendAttr(fields, groups, ga)
+		}
+		return fields
+	}
+	key := a.Key
+	for i := len(groups) - 1; i >= 0; i-- {
+		key = groups[i] + "." + key
+	}
+	return append(fields, Field{Key: key, Value: a.Value.Any()})
+}
+
+// 从调用者PC开始获取调用PC列表(跳过slog内部调用和Helper标记的函数)
+func getCallersFromPC(pc uintptr, n int) []uintptr {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	found := false
+	for i := range pcs {
+		if pcs[i] == pc {
+			pcs, found = pcs[i:], true
+			break
+		}
+	}
+	if !found {
+		return []uintptr{pc} //记录不在当前调用链中(如: 转交其他协程处理)
+	}
+	for len(pcs) > 1 && isHelper(lookupCaller(pcs[0]).function) {
+		pcs = pcs[1:]
+	}
+	if len(pcs) > n {
+		pcs = pcs[:n]
+	}
+	return pcs
+}
@@ -0,0 +1,97 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSlogScope(t *testing.T) {
+	l, output := newScopeLogger(t, 10)
+	defer l.Close()
+	s := l.Scope(context.Background())
+	ctx := s.Context()
+	logger := slog.New(NewSlogHandler(l))
+	logger.DebugContext(ctx, "d1")
+	logger.InfoContext(ctx, "i1")
+	if got := output(); got != "i1" {
+		t.Fatalf("got %q", got)
+	}
+	logger.ErrorContext(ctx, "e1")
+	logger.DebugContext(ctx, "d2")
+	if got := output(); got != "i1,d1,e1,d2" {
+		t.Fatalf("got %q", got)
+	}
+	s.Commit(nil)
+}
+
+func TestSlogFatalExit(t *testing.T) {
+	if strPath := os.Getenv("LOG_TEST_SLOG_FATAL"); strPath != "" {
+		l, err := New(strPath, Option{LogLevel: LEVEL_INFO, CloseConsole: true, Async: true, FatalExit: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slog.New(NewSlogHandler(l)).Log(context.Background(), slog.LevelError+4, "fatal")
+		t.Fatal("slog FATAL returned")
+	}
+	strPath := filepath.Join(t.TempDir(), "app.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSlogFatalExit$")
+	cmd.Env = append(os.Environ(), "LOG_TEST_SLOG_FATAL="+strPath)
+	out, err := cmd.CombinedOutput()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 || strings.Contains(string(out), "slog FATAL returned") {
+		t.Fatalf("unexpected exit %v: %s", err, out)
+	}
+	if data, err := ioutil.ReadFile(strPath); err != nil || !strings.Contains(string(data), "fatal") {
+		t.Fatalf("fatal record not flushed: %q %v", data, err)
+	}
+}
+
+func slogHelper(logger *slog.Logger, msg string) {
+	Helper()
+	logger.Info(msg)
+}
+
+func TestSlogCaller(t *testing.T) {
+	l, s := newMemoryLogger(t, Option{LogLevel: LEVEL_INFO})
+	defer l.Close()
+	logger := slog.New(NewSlogHandler(l))
+	cases := []struct {
+		name string
+		log  func() int
+	}{
+		{"direct", func() int {
+			line := callerLine() + 1
+			logger.Info("direct")
+			return line
+		}},
+		{"with", func() int {
+			line := callerLine() + 1
+			logger.With("k", "v").WithGroup("g").InfoContext(context.Background(), "with", "a", 1)
+			return line
+		}},
+		{"helper", func() int {
+			line := callerLine() + 1
+			slogHelper(logger, "helper")
+			return line
+		}},
+	}
+	for _, c := range cases {
+		n := len(s.load())
+		line := c.log()
+		records := s.load()
+		if len(records) != n+1 {
+			t.Fatalf("%s: got %d records", c.name, len(records)-n)
+		}
+		if r := records[n]; r.File != "slog_test.go" || r.Line != line {
+			t.Errorf("%s: got %s:%d, want slog_test.go:%d", c.name, r.File, r.Line, line)
+		}
+	}
+}
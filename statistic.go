@@ -3,7 +3,6 @@ package log
 import (
 	"encoding/json"
 	"fmt"
-	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
@@ -63,11 +62,10 @@ type summary struct {
 	Results  []*result `json:"statistics"`
 }
 
-var stic *statistic //数据统计对象
+var stic = newStatistic() //数据统计对象
 
 func init() {
 
-	stic = newStatistic()
 	go checkExpire(stic)
 }
 
@@ -129,22 +127,6 @@ func getResultStoreKey(strFile, strFunc string) string {
 	return fmt.Sprintf("%v:%v", strFile, strFunc)
 }
 
-func getRoutineId() (strRoutine string) {
-
-	strStack := string(debug.Stack())
-	nIdx := strings.IndexAny(strStack, ":\r\n")
-	if nIdx > 0 {
-		strRoutine = strStack[:nIdx]
-		strings.TrimSpace(strRoutine)
-		nIdx = strings.Index(strRoutine, "[")
-		if nIdx > 0 {
-			strRoutine = strRoutine[:nIdx-1]
-		}
-		return
-	}
-	return "<unknown routine>"
-}
-
 //进入方法(enter function)
 func (s *statistic) enter(strFile, strFunc string, nLineNo int) {
 	if !enableStats {
@@ -289,7 +271,7 @@ func checkExpire(stic *statistic) {
 
 				c := v.(*caller)
 				if now64 > c.ExpireTime {
-					Warn("caller key [%v] expired at [%v]", k.(string), getDatetime())
+					Warnf("caller key [%v] expired at [%v]", k.(string), getDatetime())
 					stic.callers.Delete(k)
 				}
 				return true